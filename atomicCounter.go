@@ -0,0 +1,191 @@
+package gocompute
+
+import (
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"strconv"
+)
+
+// AtomicCounter Buffer of uint32 counters usable as atomic_uint or as storage block with atomicAdd
+type AtomicCounter struct {
+	buffer *GpuBuffer
+	count  int
+}
+
+// NewAtomicCounter Creating count counters set to zero, count must be positive
+func (c *Computing) NewAtomicCounter(count int) (*AtomicCounter, error) {
+	if count <= 0 {
+		return nil, errors.New("NewAtomicCounter: count " + strconv.Itoa(count) + " must be positive")
+	}
+	counter := &AtomicCounter{buffer: c.NewBufferV(BDynamicCopy, BAtomicCounter), count: count}
+	BufferLoad(counter.buffer, make([]uint32, count))
+	return counter, nil
+}
+
+// Buffer Underlying buffer, e.g. for IndirectFromCount
+func (a *AtomicCounter) Buffer() *GpuBuffer {
+	return a.buffer
+}
+
+// Len Number of counters
+func (a *AtomicCounter) Len() int {
+	return a.count
+}
+
+// Reset Setting counters to values, missing values are zero
+func (a *AtomicCounter) Reset(values ...uint32) {
+	data := make([]uint32, a.count)
+	copy(data, values)
+	BufferPartialLoad(a.buffer, data, 0)
+}
+
+// SetBinding Binding counters to atomic counter binding point
+func (a *AtomicCounter) SetBinding(number int) {
+	a.buffer.BindBaseV(number, gl.ATOMIC_COUNTER_BUFFER)
+}
+
+// SetStorageBinding Binding counters to shader storage binding point
+func (a *AtomicCounter) SetStorageBinding(number int) {
+	a.buffer.BindBaseV(number, gl.SHADER_STORAGE_BUFFER)
+}
+
+// SetBindingName Binding counters to binding of atomic_uint uniform or storage block name in current program
+func (a *AtomicCounter) SetBindingName(name string) (err error) {
+	c := a.buffer.c
+	c.run(func() {
+		program := c.programs[c.currentProgram]
+		binding := int32(-1)
+		cName := gl.Str(name + "\x00")
+		if index := gl.GetProgramResourceIndex(program, gl.UNIFORM, cName); index != gl.INVALID_INDEX {
+			prop := uint32(gl.ATOMIC_COUNTER_BUFFER_INDEX)
+			bufferIndex := int32(-1)
+			gl.GetProgramResourceiv(program, gl.UNIFORM, index, 1, &prop, 1, nil, &bufferIndex)
+			if bufferIndex >= 0 {
+				gl.GetActiveAtomicCounterBufferiv(program, uint32(bufferIndex), gl.ATOMIC_COUNTER_BUFFER_BINDING, &binding)
+				gl.BindBufferBase(gl.ATOMIC_COUNTER_BUFFER, uint32(binding), a.buffer.id)
+			}
+		} else if index := gl.GetProgramResourceIndex(program, gl.SHADER_STORAGE_BLOCK, cName); index != gl.INVALID_INDEX {
+			prop := uint32(gl.BUFFER_BINDING)
+			gl.GetProgramResourceiv(program, gl.SHADER_STORAGE_BLOCK, index, 1, &prop, 1, nil, &binding)
+			gl.BindBufferBase(gl.SHADER_STORAGE_BUFFER, uint32(binding), a.buffer.id)
+		}
+		if binding < 0 {
+			err = errors.New("AtomicCounter: " + name + " is not an atomic counter or storage block of current program")
+		}
+		CheckErr("AtomicCounter.SetBindingName")
+	})
+	return err
+}
+
+// Read Values of every counter after writes of previous dispatches
+func (a *AtomicCounter) Read() []uint32 {
+	values := make([]uint32, a.count)
+	a.buffer.c.runShared(func() {
+		gl.MemoryBarrier(gl.ATOMIC_COUNTER_BARRIER_BIT | gl.BUFFER_UPDATE_BARRIER_BIT)
+		BufferReadInto(a.buffer, values, 0)
+	})
+	return values
+}
+
+// Value Value of counter i
+func (a *AtomicCounter) Value(i int) uint32 {
+	if i < 0 || i >= a.count {
+		println("AtomicCounter: index", i, "out of range", a.count)
+		return 0
+	}
+	values := make([]uint32, 1)
+	a.buffer.c.runShared(func() {
+		gl.MemoryBarrier(gl.ATOMIC_COUNTER_BARRIER_BIT | gl.BUFFER_UPDATE_BARRIER_BIT)
+		BufferReadInto(a.buffer, values, i*4)
+	})
+	return values[0]
+}
+
+func (a *AtomicCounter) Close() {
+	a.buffer.Close()
+}
+
+// AppendBuffer Data buffer paired with counter, kernels append with
+// uint i = atomicCounterIncrement(counter); if (i < capacity) data[i] = value;
+type AppendBuffer[T any] struct {
+	data     *GpuBuffer
+	counter  *AtomicCounter
+	capacity int
+}
+
+// NewAppendBuffer Creating empty append buffer for capacity elements
+func NewAppendBuffer[T any](c *Computing, capacity int) *AppendBuffer[T] {
+	counter, _ := c.NewAtomicCounter(1)
+	a := &AppendBuffer[T]{data: c.NewBufferV(BDynamicCopy, BStorage), counter: counter, capacity: capacity}
+	BufferAllocate[T](a.data, capacity)
+	return a
+}
+
+// Data Storage buffer of elements
+func (a *AppendBuffer[T]) Data() *GpuBuffer {
+	return a.data
+}
+
+// Counter Counter of appended elements, may exceed capacity when kernel appended too much
+func (a *AppendBuffer[T]) Counter() *AtomicCounter {
+	return a.counter
+}
+
+func (a *AppendBuffer[T]) Cap() int {
+	return a.capacity
+}
+
+// SetBinding Binding data to storage binding and counter to atomic counter binding
+func (a *AppendBuffer[T]) SetBinding(dataBinding, counterBinding int) {
+	a.data.SetBinding(dataBinding)
+	a.counter.SetBinding(counterBinding)
+}
+
+// Reset Emptying buffer before next append pass
+func (a *AppendBuffer[T]) Reset() {
+	a.counter.Reset()
+}
+
+// Len Number of stored elements, limited by capacity
+func (a *AppendBuffer[T]) Len() int {
+	count := int(a.counter.Value(0))
+	if count > a.capacity {
+		return a.capacity
+	}
+	return count
+}
+
+// Overflowed Kernel tried to append more than capacity elements
+func (a *AppendBuffer[T]) Overflowed() bool {
+	return int(a.counter.Value(0)) > a.capacity
+}
+
+// Read Copying exactly the appended elements
+func (a *AppendBuffer[T]) Read() []T {
+	count := a.Len()
+	values := make([]T, count)
+	a.data.c.runShared(func() {
+		gl.MemoryBarrier(gl.BUFFER_UPDATE_BARRIER_BIT)
+		BufferReadInto(a.data, values, 0)
+	})
+	return values
+}
+
+// ReadInto Copying appended elements into dst, returns number of copied elements
+func (a *AppendBuffer[T]) ReadInto(dst []T) int {
+	count := a.Len()
+	if count > len(dst) {
+		println("AppendBuffer: destination of", len(dst), "elements is smaller than", count)
+		count = len(dst)
+	}
+	a.data.c.runShared(func() {
+		gl.MemoryBarrier(gl.BUFFER_UPDATE_BARRIER_BIT)
+		BufferReadInto(a.data, dst[:count], 0)
+	})
+	return count
+}
+
+func (a *AppendBuffer[T]) Close() {
+	a.data.Close()
+	a.counter.Close()
+}
@@ -7,6 +7,7 @@ import "C"
 import (
 	"github.com/go-gl/gl/all-core/gl"
 	"reflect"
+	"runtime"
 	"unsafe"
 )
 
@@ -29,15 +30,20 @@ const (
 type BufferType uint32
 
 const (
-	BStorage BufferType = gl.SHADER_STORAGE_BUFFER
-	BUniform            = gl.UNIFORM_BUFFER
+	BStorage       BufferType = gl.SHADER_STORAGE_BUFFER
+	BUniform                  = gl.UNIFORM_BUFFER
+	BAtomicCounter            = gl.ATOMIC_COUNTER_BUFFER
 )
 
 type GpuBuffer struct {
 	id    uint32
 	usage BufferUsage
 	bType uint32
-	Size  int
+	// Size Elements written by last allocate or load in units of its element type,
+	// partial loads store their length. ByteSize reports allocated storage
+	Size int
+	c    *Computing
+	res  *resource
 }
 
 func (c *Computing) NewBuffer() *GpuBuffer {
@@ -47,16 +53,27 @@ func (c *Computing) NewBufferV(usage BufferUsage, bType BufferType) *GpuBuffer {
 	buffer := &GpuBuffer{}
 	buffer.usage = usage
 	buffer.bType = uint32(bType)
-	gl.GenBuffers(1, &buffer.id)
+	buffer.c = c
+	c.run(func() {
+		gl.GenBuffers(1, &buffer.id)
+	})
+	buffer.res = c.track(ResourceBuffer, buffer.id)
+	runtime.SetFinalizer(buffer, func(b *GpuBuffer) {
+		b.c.warnLeak(b.res)
+	})
 	return buffer
 }
 func (b *GpuBuffer) Bind() {
-	gl.BindBuffer(b.bType, b.id)
+	b.c.runShared(func() {
+		gl.BindBuffer(b.bType, b.id)
+	})
 }
+
+// check Buffer was closed by its own Close or freed by Computing.Close
 func (b *GpuBuffer) check() bool {
-	if b.id == 0xFFFFFFFF {
+	if b.id == 0xFFFFFFFF || b.c.isClosed(b.res) {
 		println("buffer object with ID:", b.id, "already closed!")
-		return b.id == 0xFFFFFFFF
+		return true
 	}
 	return false
 }
@@ -72,11 +89,14 @@ func BufferAllocateBytes(b *GpuBuffer, size, typeSize int) int {
 	if b.check() {
 		return 0
 	}
-	b.Bind()
-	b.Size = size / typeSize
-	//gl.BufferStorage()
-	gl.BufferData(b.bType, size*typeSize, nil, uint32(b.usage))
-	b.UnBind()
+	b.c.runShared(func() {
+		b.Bind()
+		b.Size = size
+		//gl.BufferStorage()
+		gl.BufferData(b.bType, size*typeSize, nil, uint32(b.usage))
+		b.UnBind()
+	})
+	b.c.setSize(b.res, size*typeSize)
 	return size * typeSize
 }
 
@@ -85,10 +105,13 @@ func BufferLoad[V any](b *GpuBuffer, data []V) int {
 		return 0
 	}
 	typeSize := tSizeInst[V](data)
-	b.Bind()
-	b.Size = len(data)
-	gl.BufferData(b.bType, len(data)*typeSize, unsafe.Pointer(&data[0]), uint32(b.usage))
-	b.UnBind()
+	b.c.runShared(func() {
+		b.Bind()
+		b.Size = len(data)
+		gl.BufferData(b.bType, len(data)*typeSize, unsafe.Pointer(&data[0]), uint32(b.usage))
+		b.UnBind()
+	})
+	b.c.setSize(b.res, len(data)*typeSize)
 	return len(data) * typeSize
 }
 func BufferPartialLoad[V any](b *GpuBuffer, data []V, offsetBytes int) int {
@@ -96,10 +119,12 @@ func BufferPartialLoad[V any](b *GpuBuffer, data []V, offsetBytes int) int {
 		return 0
 	}
 	typeSize := tSizeInst[V](data)
-	b.Bind()
-	b.Size = len(data)
-	gl.BufferSubData(b.bType, offsetBytes, len(data)*typeSize, unsafe.Pointer(&data[0]))
-	b.UnBind()
+	b.c.runShared(func() {
+		b.Bind()
+		b.Size = len(data)
+		gl.BufferSubData(b.bType, offsetBytes, len(data)*typeSize, unsafe.Pointer(&data[0]))
+		b.UnBind()
+	})
 	return len(data) * typeSize
 }
 
@@ -107,10 +132,17 @@ func (b *GpuBuffer) SetBinding(number int) {
 	b.BindBaseV(number, b.bType)
 }
 func (b *GpuBuffer) BindBaseV(number int, tType uint32) {
-	gl.BindBufferBase(tType, uint32(number), b.id)
+	if b.check() {
+		return
+	}
+	b.c.runShared(func() {
+		gl.BindBufferBase(tType, uint32(number), b.id)
+	})
 }
 func (b *GpuBuffer) UnBind() {
-	gl.BindBuffer(b.bType, 0)
+	b.c.runShared(func() {
+		gl.BindBuffer(b.bType, 0)
+	})
 }
 
 func toSlice[V any](pointer unsafe.Pointer, size int) []V {
@@ -121,33 +153,37 @@ func toSlice[V any](pointer unsafe.Pointer, size int) []V {
 	sh.Cap = size
 	return output
 }
+
+// BufferRead Copying size elements into new slice, mapped memory is not valid after unmap
 func BufferRead[V any](b *GpuBuffer, size int) []V {
 	if b.check() {
 		return nil
 	}
-	typeSize := tSize[V]()
-	b.Bind()
-	buffer := gl.MapBufferRange(b.bType, 0, size*typeSize, gl.MAP_READ_BIT)
-	slice := toSlice[V](buffer, size)
-	gl.UnmapBuffer(b.bType)
-	CheckErr("BufferRead")
-	//b.UnBind()
+	slice := make([]V, size)
+	BufferReadInto(b, slice, 0)
 	return slice
 }
 func BufferReadRange[V any](b *GpuBuffer, min, extent int) []V {
 	if b.check() {
 		return nil
 	}
-	typeSize := tSize[V]()
-	b.Bind()
-	buffer := gl.MapBufferRange(b.bType, min, extent*typeSize, gl.MAP_READ_BIT)
-	slice := toSlice[V](buffer, extent)
-	gl.UnmapBuffer(b.bType)
-	CheckErr("BufferReadRange")
-	//b.UnBind()
+	slice := make([]V, extent)
+	BufferReadInto(b, slice, min)
 	return slice
 }
 
+// BufferReadInto Copying len(dst) elements starting at offsetBytes into caller slice
+func BufferReadInto[V any](b *GpuBuffer, dst []V, offsetBytes int) {
+	if b.check() || len(dst) == 0 {
+		return
+	}
+	b.c.runShared(func() {
+		b.Bind()
+		gl.GetBufferSubData(b.bType, offsetBytes, len(dst)*tSize[V](), unsafe.Pointer(&dst[0]))
+		CheckErr("BufferReadInto")
+	})
+}
+
 func (b *GpuBuffer) Allocate(size int) {
 	BufferAllocate[byte](b, size)
 }
@@ -188,6 +224,18 @@ func (b *GpuBuffer) LoadFloat64(data []float64) int {
 }
 
 func (b *GpuBuffer) Close() {
-	gl.DeleteBuffers(1, &b.id)
-	b.id = 0xFFFFFFFF
+	if !b.c.untrack(b.res) {
+		return
+	}
+	b.c.runShared(func() {
+		gl.DeleteBuffers(1, &b.id)
+		b.id = 0xFFFFFFFF
+	})
+}
+
+// ByteSize Allocated storage in bytes
+func (b *GpuBuffer) ByteSize() int {
+	b.c.resourceMutex.Lock()
+	defer b.c.resourceMutex.Unlock()
+	return b.res.Size
 }
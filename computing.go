@@ -7,6 +7,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 	"unsafe"
 )
 
@@ -15,14 +16,23 @@ type computeGroup struct {
 }
 
 type Computing struct {
-	currentProgram  int
-	includeLoader   func(name string) string
-	version         string
-	programCounter  int
-	programs        map[int]uint32
-	maxComputeGroup computeGroup
-	computeGroups   map[int]*computeGroup
-	defineMap       map[string]string
+	currentProgram int
+	includeLoader  func(name string) string
+	version        string
+	programCounter int
+	programs       map[int]uint32
+	computeGroups  map[int]*computeGroup
+	defineMap      map[string]string
+	thread         *glThread
+	context        Context
+	group          *shareGroup
+	resources      map[resourceKey]*resource
+	resourceMutex  sync.Mutex
+	debug          bool
+	programCache   *ProgramCache
+	device         *DeviceInfo
+	builtins       map[string]int
+	tuning         *TuningDB
 }
 
 func CheckErr(operation string) {
@@ -44,18 +54,32 @@ func NewComputing() (*Computing, error) {
 	compute.programs = make(map[int]uint32)
 	compute.defineMap = make(map[string]string)
 	compute.computeGroups = make(map[int]*computeGroup)
+	compute.resources = make(map[resourceKey]*resource)
+	compute.builtins = make(map[string]int)
 	return compute, nil
 }
 
-func (c *Computing) GetCurrentProgramID() uint32 {
-	return c.programs[c.currentProgram]
+func (c *Computing) GetCurrentProgramID() (id uint32) {
+	c.run(func() {
+		id = c.programs[c.currentProgram]
+	})
+	return id
 }
 
-func (c *Computing) GetUniformLocation(name string) int32 {
-	return gl.GetUniformLocation(c.programs[c.currentProgram], gl.Str(name+"\x00"))
+func (c *Computing) GetUniformLocation(name string) (location int32) {
+	c.run(func() {
+		location = gl.GetUniformLocation(c.programs[c.currentProgram], gl.Str(name+"\x00"))
+	})
+	return location
 }
 
 func (c *Computing) SetInt(name string, input ...int) {
+	c.run(func() {
+		c.setInt(name, input...)
+	})
+}
+
+func (c *Computing) setInt(name string, input ...int) {
 	address := c.GetUniformLocation(name)
 	if address == -1 {
 		println("SetInt uniform:", name, "not found")
@@ -74,6 +98,12 @@ func (c *Computing) SetInt(name string, input ...int) {
 }
 
 func (c *Computing) SetFloat32(name string, input ...float32) {
+	c.run(func() {
+		c.setFloat32(name, input...)
+	})
+}
+
+func (c *Computing) setFloat32(name string, input ...float32) {
 	address := c.GetUniformLocation(name)
 	if address == -1 {
 		println("SetFloat32 uniform:", name, "not found")
@@ -121,23 +151,135 @@ func compileShader(shaderType int, shaderProgram string) (uint32, error) {
 	}
 	return shaderHandle, nil
 }
-func (c *Computing) LoadProgram(programText string) (int, error) {
+func (c *Computing) LoadProgram(programText string) (id int, err error) {
+	c.run(func() {
+		id, err = c.loadProgram(programText)
+	})
+	return id, err
+}
+
+func (c *Computing) loadProgram(programText string) (int, error) {
 	count := c.programCounter
 	c.computeGroups[count] = &computeGroup{1, 1, 1}
-	programText = c.preProcess(programText)
+	if tuned := c.tunedDefines(programText); tuned != nil {
+		defer c.withDefines(tuned)()
+	}
+	programText = c.preProcess(programText, c.computeGroups[count], c.includeLoader)
+	program, err := c.buildProgram(programText)
+	if err != nil {
+		return 0, err
+	}
+	c.programs[count] = program
+	c.programCounter++
+	return count, nil
+}
+
+// buildProgram Compiling and linking preprocessed compute shader, shader is deleted after linking
+// With program cache binary of same source, defines and driver is reused
+func (c *Computing) buildProgram(programText string) (uint32, error) {
+	key := ""
+	if c.programCache != nil && c.deviceInfo().Supports(FeatureProgramBinary) {
+		key = c.cacheKey(programText)
+		if program := c.loadCachedProgram(key); program != 0 {
+			c.track(ResourceProgram, program)
+			return program, nil
+		}
+	}
 	shaderHandle, err := compileShader(gl.COMPUTE_SHADER, programText)
 	if err != nil {
 		return 0, err
 	}
+	shader := c.track(ResourceShader, shaderHandle)
 	program := gl.CreateProgram()
+	if key != "" {
+		gl.ProgramParameteri(program, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	}
 	gl.AttachShader(program, shaderHandle)
 	gl.LinkProgram(program)
-	c.programs[count] = program
-	c.programCounter++
-	return count, nil
+	gl.DetachShader(program, shaderHandle)
+	gl.DeleteShader(shaderHandle)
+	c.untrack(shader)
+	linkStatus := int32(0)
+	gl.GetProgramiv(program, gl.LINK_STATUS, &linkStatus)
+	if linkStatus == gl.FALSE {
+		outLen := int32(0)
+		var infoLog [1024]byte
+		gl.GetProgramInfoLog(program, 1024, &outLen, &(infoLog[0]))
+		gl.DeleteProgram(program)
+		return 0, errors.New("Error linking program: " + string(infoLog[:outLen]))
+	}
+	if key != "" {
+		c.storeCachedProgram(key, program)
+	}
+	c.track(ResourceProgram, program)
+	return program, nil
 }
+
+// DeleteProgram Deleting program, id is not reused by later LoadProgram calls
+func (c *Computing) DeleteProgram(id int) (err error) {
+	c.run(func() {
+		program, ok := c.programs[id]
+		if !ok {
+			err = errors.New("DeleteProgram: program " + strconv.Itoa(id) + " not found")
+			return
+		}
+		if c.currentProgram == id {
+			gl.UseProgram(0)
+		}
+		c.deleteProgramObject(program)
+		delete(c.programs, id)
+		delete(c.computeGroups, id)
+	})
+	return err
+}
+
+// ReplaceProgram Compiling new source under existing id, old program stays active when compilation fails
+func (c *Computing) ReplaceProgram(id int, programText string) error {
+	return c.replaceProgram(id, programText, c.includeLoader)
+}
+
+func (c *Computing) replaceProgram(id int, programText string, includeLoader func(name string) string) (err error) {
+	c.run(func() {
+		old, ok := c.programs[id]
+		if !ok {
+			err = errors.New("ReplaceProgram: program " + strconv.Itoa(id) + " not found")
+			return
+		}
+		group := &computeGroup{1, 1, 1}
+		if tuned := c.tunedDefines(programText); tuned != nil {
+			defer c.withDefines(tuned)()
+		}
+		programText = c.preProcess(programText, group, includeLoader)
+		program, buildErr := c.buildProgram(programText)
+		if buildErr != nil {
+			err = buildErr
+			return
+		}
+		c.programs[id] = program
+		c.computeGroups[id] = group
+		if c.currentProgram == id {
+			gl.UseProgram(program)
+		}
+		c.deleteProgramObject(old)
+	})
+	return err
+}
+
+func (c *Computing) deleteProgramObject(program uint32) {
+	c.resourceMutex.Lock()
+	r := c.resources[resourceKey{ResourceProgram, program}]
+	c.resourceMutex.Unlock()
+	if r != nil {
+		c.untrack(r)
+	}
+	gl.DeleteProgram(program)
+	CheckErr("DeleteProgram")
+}
+
 func (c *Computing) Define(Name string, value string) {
-	c.defineMap[Name] = value
+	c.run(func() {
+		c.defineMap[Name] = value
+	})
 }
 
 func (c *Computing) DefineInt(Name string, value int) {
@@ -153,48 +295,66 @@ func (c *Computing) DefineDouble(Name string, value float64) {
 }
 
 func (c *Computing) UseProgram(programNumber int) {
-	if len(c.defineMap) > 0 {
-		log.Println("Warning: using defines with preloaded program")
-	}
-	c.currentProgram = programNumber
-	gl.UseProgram(c.programs[c.currentProgram])
-	CheckErr("UseProgram")
+	c.run(func() {
+		if len(c.defineMap) > 0 {
+			log.Println("Warning: using defines with preloaded program")
+		}
+		c.currentProgram = programNumber
+		gl.UseProgram(c.programs[c.currentProgram])
+		CheckErr("UseProgram")
+	})
 }
 func (c *Computing) SetIncludeLoader(loader func(name string) string) {
 	if loader != nil {
-		c.includeLoader = loader
+		c.run(func() {
+			c.includeLoader = loader
+		})
 	}
 }
 func (c *Computing) Realize(x, y, z int) {
-	gl.DispatchCompute(uint32(x), uint32(y), uint32(z))
+	c.run(func() {
+		gl.DispatchCompute(uint32(x), uint32(y), uint32(z))
+	})
+}
+
+// MemoryBarrier Making shader writes visible to operations selected by gl barrier bits
+func (c *Computing) MemoryBarrier(bits uint32) {
+	c.run(func() {
+		gl.MemoryBarrier(bits)
+	})
 }
 
 func (c *Computing) UseLoadProgram(programText string) {
-	c.defineMap = make(map[string]string)
-	programText = c.preProcess(programText)
-	shaderHandle, _ := compileShader(gl.COMPUTE_SHADER, programText)
-	program := gl.CreateProgram()
-	gl.AttachShader(program, shaderHandle)
-	gl.LinkProgram(program)
+	c.run(func() {
+		c.defineMap = make(map[string]string)
+		programText = c.preProcess(programText, &computeGroup{1, 1, 1}, c.includeLoader)
+		c.buildProgram(programText)
+	})
 }
 
-func (c *Computing) preProcess(computeProgram string) string {
+// preProcess Resolving includes and defines, local sizes from layout are written into group
+func (c *Computing) preProcess(computeProgram string, group *computeGroup, includeLoader func(name string) string) string {
 	scanner := bufio.NewScanner(strings.NewReader(computeProgram))
 	lines := ""
 	versioned := false
 	lineCnt := 0
+	//Values of defines seen so far, layout may take local size from them
+	defined := make(map[string]string)
 	for scanner.Scan() {
 		lineCnt++
 		text := scanner.Text()
 		switch {
 		case strings.Contains(text, "#include"):
 			split := strings.Split(text, " ")
-			text = c.includeLoader(split[len(split)-1])
+			text = includeLoader(split[len(split)-1])
 		case strings.Contains(text, "#define"):
 			split := strings.Split(text, " ")
 			res := c.defineMap[split[1]]
 			if res != "" {
-				text = "#define " + split[1] + res
+				text = "#define " + split[1] + " " + res
+			}
+			if fields := strings.Fields(text); len(fields) >= 3 {
+				defined[fields[1]] = fields[2]
 			}
 		case strings.Contains(text, "main()"):
 			text = "uniform ivec3 computeoffset;\n#line " + strconv.Itoa(lineCnt-1) + "\n" + text
@@ -207,15 +367,19 @@ func (c *Computing) preProcess(computeProgram string) string {
 			inSplit := strings.Split(input, ",")
 			for _, str := range inSplit {
 				nv := strings.Split(str, "=")
-				parsed, err := strconv.ParseInt(nv[len(nv)-1], 10, 64)
+				value := nv[len(nv)-1]
+				if define, ok := defined[value]; ok {
+					value = define
+				}
+				parsed, err := strconv.ParseInt(value, 10, 64)
 				if err == nil {
 					switch nv[0] {
 					case "local_size_x":
-						c.computeGroups[c.programCounter].X = int(parsed)
+						group.X = int(parsed)
 					case "local_size_y":
-						c.computeGroups[c.programCounter].Y = int(parsed)
+						group.Y = int(parsed)
 					case "local_size_z":
-						c.computeGroups[c.programCounter].Z = int(parsed)
+						group.Z = int(parsed)
 					}
 				}
 			}
@@ -240,6 +404,10 @@ func tSizeInst[V any](d []V) int {
 	return int(unsafe.Sizeof(d[0]))
 }
 
+// Close Deleting every program, shader, buffer, texture and sampler created by Computing
+// Objects closed later by their own Close are ignored
 func (c *Computing) Close() {
-
+	c.run(c.freeResources)
+	c.releaseContext()
+	c.stopThread()
 }
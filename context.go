@@ -0,0 +1,126 @@
+package gocompute
+
+import (
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"sync"
+)
+
+// Context GL context which can be owned by Computing
+// Implementations are expected to create 4.3+ core contexts, see glfwcontext package
+type Context interface {
+	// MakeCurrent Making context current on calling OS thread
+	MakeCurrent() error
+	// ReleaseCurrent Detaching context from calling OS thread
+	ReleaseCurrent() error
+	// Share Creating new context sharing buffers, textures and programs with this one
+	Share() (Context, error)
+	// Destroy Releasing context, it must not be current anywhere
+	Destroy()
+}
+
+// shareGroup Computing values whose contexts share objects
+type shareGroup struct {
+	mutex   sync.RWMutex
+	threads map[uint64]*Computing
+}
+
+func (g *shareGroup) add(c *Computing) {
+	g.mutex.Lock()
+	g.threads[c.thread.id] = c
+	g.mutex.Unlock()
+}
+
+func (g *shareGroup) remove(c *Computing) {
+	g.mutex.Lock()
+	delete(g.threads, c.thread.id)
+	g.mutex.Unlock()
+}
+
+// contains Goroutine is GL thread of group member
+func (g *shareGroup) contains(id uint64) bool {
+	g.mutex.RLock()
+	_, ok := g.threads[id]
+	g.mutex.RUnlock()
+	return ok
+}
+
+// member Computing whose GL thread has id, nil for other threads
+func (g *shareGroup) member(id uint64) *Computing {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return g.threads[id]
+}
+
+var glInit sync.Once
+var glInitErr error
+
+// NewComputingContext Creating Computing owning ctx
+// Commands run on own locked thread where ctx is current, Close releases and destroys ctx
+func NewComputingContext(ctx Context) (*Computing, error) {
+	return newComputingContext(ctx, &shareGroup{threads: make(map[uint64]*Computing)})
+}
+
+func newComputingContext(ctx Context, group *shareGroup) (*Computing, error) {
+	c, err := NewComputingThread(func() error {
+		if err := ctx.MakeCurrent(); err != nil {
+			return err
+		}
+		glInit.Do(func() {
+			glInitErr = gl.Init()
+		})
+		return glInitErr
+	})
+	if err != nil {
+		ctx.Destroy()
+		return nil, err
+	}
+	c.context = ctx
+	c.group = group
+	group.add(c)
+	//Every program is a compute shader, fail before the first LoadProgram
+	if err := c.Require(FeatureCompute); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Shared Creating Computing with context sharing buffers, textures and program objects with c
+// Objects may be used from any member of group, writes must be finished with Finish before other contexts read them
+func (c *Computing) Shared() (*Computing, error) {
+	if c.context == nil {
+		return nil, errors.New("Shared: Computing doesn't own context")
+	}
+	ctx, err := c.context.Share()
+	if err != nil {
+		return nil, err
+	}
+	return newComputingContext(ctx, c.group)
+}
+
+// Context Owned context, nil when Computing uses context current on caller thread
+func (c *Computing) Context() Context {
+	return c.context
+}
+
+// MakeCurrent Restoring owned context on GL thread, after external code switched contexts there
+func (c *Computing) MakeCurrent() error {
+	if c.context == nil {
+		return errors.New("MakeCurrent: Computing doesn't own context")
+	}
+	return c.DoErr(c.context.MakeCurrent)
+}
+
+// releaseContext Detaching and destroying owned context on GL thread
+func (c *Computing) releaseContext() {
+	if c.context == nil {
+		return
+	}
+	c.group.remove(c)
+	c.run(func() {
+		c.context.ReleaseCurrent()
+	})
+	c.context.Destroy()
+	c.context = nil
+}
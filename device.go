@@ -0,0 +1,252 @@
+package gocompute
+
+import (
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"sort"
+	"strconv"
+)
+
+// Feature Optional driver capability, names not declared below are checked as extension names
+type Feature string
+
+const (
+	// FeatureCompute Compute shaders, GL 4.3 or GL_ARB_compute_shader
+	FeatureCompute Feature = "compute"
+	// FeatureSPIRV SPIR-V shader binaries, GL 4.6 or GL_ARB_gl_spirv
+	FeatureSPIRV Feature = "spirv"
+	// FeatureProgramBinary Retrievable program binaries used by ProgramCache
+	FeatureProgramBinary Feature = "program binary"
+	// FeatureFloat64 Double precision in shaders, GL 4.0 or GL_ARB_gpu_shader_fp64
+	FeatureFloat64 Feature = "float64"
+	// FeatureInt64 64-bit integers in shaders, GL_ARB_gpu_shader_int64
+	FeatureInt64 Feature = "int64"
+	// FeatureAtomicFloat Float atomicAdd on buffers and shared memory, GL_NV_shader_atomic_float
+	FeatureAtomicFloat Feature = "atomic float"
+	// FeatureSubgroups Subgroup operations, GL_KHR_shader_subgroup
+	FeatureSubgroups Feature = "subgroups"
+)
+
+// ImageFormat Texture type and channels usable with image load store on device
+type ImageFormat struct {
+	Type     TextureType
+	Channels int
+	Layout   string
+}
+
+// DeviceInfo Driver identification and compute limits of context
+type DeviceInfo struct {
+	Vendor   string
+	Renderer string
+	// Version full GL version string, Major and Minor are parsed by driver
+	Version      string
+	Major, Minor int
+	GLSLVersion  string
+	Extensions   []string
+	// MaxWorkGroupCount work groups of single dispatch per dimension
+	MaxWorkGroupCount [3]int
+	// MaxWorkGroupSize local size per dimension
+	MaxWorkGroupSize        [3]int
+	MaxWorkGroupInvocations int
+	// MaxSharedMemory bytes of shared variables per work group
+	MaxSharedMemory int
+	// MaxStorageBlockSize bytes of single shader storage block
+	MaxStorageBlockSize int64
+	// MaxStorageBindings storage buffer binding points of context
+	MaxStorageBindings int
+	// MaxComputeStorageBlocks storage blocks usable by one compute shader
+	MaxComputeStorageBlocks int
+	MaxImageUnits           int
+	// MaxComputeImageUniforms images usable by one compute shader
+	MaxComputeImageUniforms int
+	MaxComputeUniforms      int
+	ShaderBinaryFormats     []uint32
+	ProgramBinaryFormats    int
+	// ImageFormats formats supported by image load and store
+	ImageFormats []ImageFormat
+}
+
+// AtLeast GL version is major.minor or newer
+func (d DeviceInfo) AtLeast(major, minor int) bool {
+	return d.Major > major || d.Major == major && d.Minor >= minor
+}
+
+// HasExtension Driver reports extension
+func (d DeviceInfo) HasExtension(name string) bool {
+	for _, extension := range d.Extensions {
+		if extension == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports Device has feature
+func (d DeviceInfo) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureCompute:
+		return d.AtLeast(4, 3) || d.HasExtension("GL_ARB_compute_shader")
+	case FeatureSPIRV:
+		for _, format := range d.ShaderBinaryFormats {
+			if format == gl.SHADER_BINARY_FORMAT_SPIR_V {
+				return true
+			}
+		}
+		return false
+	case FeatureProgramBinary:
+		return d.ProgramBinaryFormats > 0
+	case FeatureFloat64:
+		return d.AtLeast(4, 0) || d.HasExtension("GL_ARB_gpu_shader_fp64")
+	case FeatureInt64:
+		return d.HasExtension("GL_ARB_gpu_shader_int64") || d.HasExtension("GL_AMD_gpu_shader_int64")
+	case FeatureAtomicFloat:
+		return d.HasExtension("GL_NV_shader_atomic_float")
+	case FeatureSubgroups:
+		return d.HasExtension("GL_KHR_shader_subgroup")
+	}
+	return d.HasExtension(string(feature))
+}
+
+// Require Error naming device and every missing feature
+func (d DeviceInfo) Require(features ...Feature) error {
+	missing := ""
+	for _, feature := range features {
+		if !d.Supports(feature) {
+			if missing != "" {
+				missing += ", "
+			}
+			missing += string(feature)
+		}
+	}
+	if missing == "" {
+		return nil
+	}
+	return errors.New("device " + d.Renderer + " (GL " + d.Version + ") doesn't support " + missing)
+}
+
+// String Short description of device
+func (d DeviceInfo) String() string {
+	return d.Vendor + " " + d.Renderer + ", GL " + strconv.Itoa(d.Major) + "." + strconv.Itoa(d.Minor) + ", GLSL " + d.GLSLVersion
+}
+
+// driver Vendor, renderer and version, program binaries and tuning results are only valid for same driver
+func (d DeviceInfo) driver() string {
+	return d.Vendor + "\n" + d.Renderer + "\n" + d.Version
+}
+
+// SupportsImageFormat Texture type with channels can be bound with SetBinding on device
+func (d DeviceInfo) SupportsImageFormat(texType TextureType, channels int) bool {
+	for _, format := range d.ImageFormats {
+		if format.Type == texType && format.Channels == channels {
+			return true
+		}
+	}
+	return false
+}
+
+func getInt(name uint32) int {
+	value := int32(0)
+	gl.GetIntegerv(name, &value)
+	return int(value)
+}
+
+// imageFormats Known image load store formats reported usable by driver
+func imageFormats() []ImageFormat {
+	types := make([]TextureType, 0, len(textureTypeNames))
+	for texType := range textureTypeNames {
+		types = append(types, texType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+	var formats []ImageFormat
+	for _, texType := range types {
+		for channels := 1; channels <= 4; channels++ {
+			format, err := TextureFormatOf(texType, channels)
+			if err != nil || !format.ImageLoadStore() {
+				continue
+			}
+			load, store := int32(0), int32(0)
+			gl.GetInternalformativ(gl.TEXTURE_2D, format.Internal, gl.SHADER_IMAGE_LOAD, 1, &load)
+			gl.GetInternalformativ(gl.TEXTURE_2D, format.Internal, gl.SHADER_IMAGE_STORE, 1, &store)
+			if load != gl.NONE && store != gl.NONE {
+				formats = append(formats, ImageFormat{texType, channels, format.Layout})
+			}
+		}
+	}
+	return formats
+}
+
+// queryDevice Reading driver strings and limits of current context
+func queryDevice() *DeviceInfo {
+	d := &DeviceInfo{
+		Vendor:      gl.GoStr(gl.GetString(gl.VENDOR)),
+		Renderer:    gl.GoStr(gl.GetString(gl.RENDERER)),
+		Version:     gl.GoStr(gl.GetString(gl.VERSION)),
+		Major:       getInt(gl.MAJOR_VERSION),
+		Minor:       getInt(gl.MINOR_VERSION),
+		GLSLVersion: gl.GoStr(gl.GetString(gl.SHADING_LANGUAGE_VERSION)),
+	}
+	for i := 0; i < getInt(gl.NUM_EXTENSIONS); i++ {
+		d.Extensions = append(d.Extensions, gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i))))
+	}
+	if d.Supports(FeatureCompute) {
+		for i := uint32(0); i < 3; i++ {
+			count, size := int32(0), int32(0)
+			gl.GetIntegeri_v(gl.MAX_COMPUTE_WORK_GROUP_COUNT, i, &count)
+			gl.GetIntegeri_v(gl.MAX_COMPUTE_WORK_GROUP_SIZE, i, &size)
+			d.MaxWorkGroupCount[i], d.MaxWorkGroupSize[i] = int(count), int(size)
+		}
+		d.MaxWorkGroupInvocations = getInt(gl.MAX_COMPUTE_WORK_GROUP_INVOCATIONS)
+		d.MaxSharedMemory = getInt(gl.MAX_COMPUTE_SHARED_MEMORY_SIZE)
+		d.MaxComputeStorageBlocks = getInt(gl.MAX_COMPUTE_SHADER_STORAGE_BLOCKS)
+		d.MaxComputeImageUniforms = getInt(gl.MAX_COMPUTE_IMAGE_UNIFORMS)
+		d.MaxComputeUniforms = getInt(gl.MAX_COMPUTE_UNIFORM_COMPONENTS)
+		gl.GetInteger64v(gl.MAX_SHADER_STORAGE_BLOCK_SIZE, &d.MaxStorageBlockSize)
+		d.MaxStorageBindings = getInt(gl.MAX_SHADER_STORAGE_BUFFER_BINDINGS)
+		d.ImageFormats = imageFormats()
+	}
+	d.MaxImageUnits = getInt(gl.MAX_IMAGE_UNITS)
+	if count := getInt(gl.NUM_SHADER_BINARY_FORMATS); count > 0 {
+		formats := make([]int32, count)
+		gl.GetIntegerv(gl.SHADER_BINARY_FORMATS, &formats[0])
+		for _, format := range formats {
+			d.ShaderBinaryFormats = append(d.ShaderBinaryFormats, uint32(format))
+		}
+	}
+	d.ProgramBinaryFormats = getInt(gl.NUM_PROGRAM_BINARY_FORMATS)
+	CheckErr("DeviceInfo")
+	return d
+}
+
+// deviceInfo Limits of context queried once, must run on GL thread
+func (c *Computing) deviceInfo() *DeviceInfo {
+	if c.device == nil {
+		c.device = queryDevice()
+	}
+	return c.device
+}
+
+// DeviceInfo Driver identification, limits and extensions of context
+func (c *Computing) DeviceInfo() (info DeviceInfo) {
+	c.run(func() {
+		info = *c.deviceInfo()
+	})
+	return info
+}
+
+// Supports Context has feature, see Feature constants
+func (c *Computing) Supports(feature Feature) (supported bool) {
+	c.run(func() {
+		supported = c.deviceInfo().Supports(feature)
+	})
+	return supported
+}
+
+// Require Error when context lacks any of features
+func (c *Computing) Require(features ...Feature) (err error) {
+	c.run(func() {
+		err = c.deviceInfo().Require(features...)
+	})
+	return err
+}
@@ -0,0 +1,437 @@
+package gocompute
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-gl/gl/all-core/gl"
+	"regexp"
+	"strings"
+)
+
+// ElementwiseKind Storage of elementwise kernel parameter
+type ElementwiseKind int
+
+const (
+	// EScalar Uniform value shared by every element
+	EScalar ElementwiseKind = iota
+	// EBuffer Storage buffer array indexed by element
+	EBuffer
+	// EImage Float 2D image, elements are texels in row order and have vec4 type
+	EImage
+)
+
+// ElementwiseParam Named typed argument of elementwise kernel
+type ElementwiseParam struct {
+	Name string
+	// Type GLSL type of one element or of scalar
+	Type   string
+	Kind   ElementwiseKind
+	Input  bool
+	Output bool
+}
+
+// ArgIn Buffer read by kernel
+func ArgIn(name, glslType string) ElementwiseParam {
+	return ElementwiseParam{Name: name, Type: glslType, Kind: EBuffer, Input: true}
+}
+
+// ArgOut Buffer written by kernel, value starts at zero
+func ArgOut(name, glslType string) ElementwiseParam {
+	return ElementwiseParam{Name: name, Type: glslType, Kind: EBuffer, Output: true}
+}
+
+// ArgInOut Buffer updated in place
+func ArgInOut(name, glslType string) ElementwiseParam {
+	return ElementwiseParam{Name: name, Type: glslType, Kind: EBuffer, Input: true, Output: true}
+}
+
+// ArgImageIn Texture read by kernel as vec4
+func ArgImageIn(name string) ElementwiseParam {
+	return ElementwiseParam{Name: name, Type: "vec4", Kind: EImage, Input: true}
+}
+
+// ArgImageOut Texture written by kernel from vec4
+func ArgImageOut(name string) ElementwiseParam {
+	return ElementwiseParam{Name: name, Type: "vec4", Kind: EImage, Output: true}
+}
+
+// ArgImageInOut Texture updated in place
+func ArgImageInOut(name string) ElementwiseParam {
+	return ElementwiseParam{Name: name, Type: "vec4", Kind: EImage, Input: true, Output: true}
+}
+
+// ArgScalar Uniform passed on every run
+func ArgScalar(name, glslType string) ElementwiseParam {
+	return ElementwiseParam{Name: name, Type: glslType, Kind: EScalar, Input: true}
+}
+
+// elementwiseGroup Local size of generated kernels
+const elementwiseGroup = 256
+
+// elementSizes Bytes per std430 array element, vec3 types are left out because of their 16 byte stride
+var elementSizes = map[string]int{
+	"float": 4, "int": 4, "uint": 4,
+	"vec2": 8, "ivec2": 8, "uvec2": 8,
+	"vec4": 16, "ivec4": 16, "uvec4": 16,
+}
+
+// scalarSizes Components of uniform types
+var scalarSizes = map[string]int{
+	"float": 1, "int": 1, "uint": 1,
+	"vec2": 2, "vec3": 3, "vec4": 4,
+	"ivec2": 2, "ivec3": 3, "ivec4": 4,
+}
+
+var paramName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// glslReserved GLSL 4.30 keywords and reserved words, they can't name parameters
+var glslReserved = wordSet(`attribute const uniform varying buffer shared coherent volatile restrict readonly writeonly
+		atomic_uint layout centroid flat smooth noperspective patch sample break continue do for while switch case default
+		if else subroutine in out inout float double int void bool true false invariant precise discard return struct
+		mat2 mat3 mat4 dmat2 dmat3 dmat4 mat2x2 mat2x3 mat2x4 dmat2x2 dmat2x3 dmat2x4 mat3x2 mat3x3 mat3x4 dmat3x2 dmat3x3 dmat3x4
+		mat4x2 mat4x3 mat4x4 dmat4x2 dmat4x3 dmat4x4 vec2 vec3 vec4 ivec2 ivec3 ivec4 bvec2 bvec3 bvec4 dvec2 dvec3 dvec4
+		uint uvec2 uvec3 uvec4 lowp mediump highp precision
+		sampler1D sampler2D sampler3D samplerCube sampler1DShadow sampler2DShadow samplerCubeShadow sampler1DArray sampler2DArray
+		sampler1DArrayShadow sampler2DArrayShadow isampler1D isampler2D isampler3D isamplerCube isampler1DArray isampler2DArray
+		usampler1D usampler2D usampler3D usamplerCube usampler1DArray usampler2DArray sampler2DRect sampler2DRectShadow
+		isampler2DRect usampler2DRect samplerBuffer isamplerBuffer usamplerBuffer samplerCubeArray samplerCubeArrayShadow
+		isamplerCubeArray usamplerCubeArray sampler2DMS isampler2DMS usampler2DMS sampler2DMSArray isampler2DMSArray usampler2DMSArray
+		image1D iimage1D uimage1D image2D iimage2D uimage2D image3D iimage3D uimage3D image2DRect iimage2DRect uimage2DRect
+		imageCube iimageCube uimageCube imageBuffer iimageBuffer uimageBuffer image1DArray iimage1DArray uimage1DArray
+		image2DArray iimage2DArray uimage2DArray imageCubeArray iimageCubeArray uimageCubeArray
+		image2DMS iimage2DMS uimage2DMS image2DMSArray iimage2DMSArray uimage2DMSArray
+		common partition active asm class union enum typedef template this resource goto inline noinline public static
+		extern external interface long short half fixed unsigned superp input output hvec2 hvec3 hvec4 fvec2 fvec3 fvec4
+		sampler3DRect filter sizeof cast namespace using main`)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// ElementwiseKernel Compute shader generated from GLSL statement run once per element
+// Body sees every parameter as local variable of its type and element index as int i
+type ElementwiseKernel struct {
+	c        *Computing
+	name     string
+	body     string
+	preamble string
+	params   []ElementwiseParam
+}
+
+// NewElementwise Kernel running body for every element, like "z = a * x + y"
+func (c *Computing) NewElementwise(name, body string, params ...ElementwiseParam) (*ElementwiseKernel, error) {
+	names := make(map[string]bool)
+	outputs := 0
+	for _, p := range params {
+		if !paramName.MatchString(p.Name) || strings.HasPrefix(p.Name, "gl_") || strings.HasPrefix(p.Name, "ew_") ||
+			strings.Contains(p.Name, "__") || p.Name == "i" || glslReserved[p.Name] {
+			return nil, errors.New("NewElementwise " + name + ": invalid parameter name \"" + p.Name + "\"")
+		}
+		if names[p.Name] {
+			return nil, errors.New("NewElementwise " + name + ": duplicate parameter " + p.Name)
+		}
+		names[p.Name] = true
+		switch p.Kind {
+		case EScalar:
+			if p.Output {
+				return nil, errors.New("NewElementwise " + name + ": scalar " + p.Name + " can't be output")
+			}
+			if scalarSizes[p.Type] == 0 {
+				return nil, errors.New("NewElementwise " + name + ": unsupported scalar type " + p.Type)
+			}
+		case EBuffer:
+			if elementSizes[p.Type] == 0 {
+				return nil, errors.New("NewElementwise " + name + ": unsupported buffer element type " + p.Type)
+			}
+		case EImage:
+			if p.Type != "vec4" {
+				return nil, errors.New("NewElementwise " + name + ": image " + p.Name + " must have vec4 type")
+			}
+		default:
+			return nil, errors.New("NewElementwise " + name + ": unknown kind of parameter " + p.Name)
+		}
+		if p.Output {
+			outputs++
+		}
+	}
+	if outputs == 0 {
+		return nil, errors.New("NewElementwise " + name + ": kernel has no output")
+	}
+	return &ElementwiseKernel{c: c, name: name, body: strings.TrimSpace(body), params: params}, nil
+}
+
+// Preamble GLSL functions and constants declared before main, usable from body
+func (k *ElementwiseKernel) Preamble(code string) *ElementwiseKernel {
+	k.preamble = code
+	return k
+}
+
+// Params Parameters in argument order
+func (k *ElementwiseKernel) Params() []ElementwiseParam {
+	return k.params
+}
+
+func memoryAccess(p ElementwiseParam) string {
+	switch {
+	case p.Input && p.Output:
+		return ""
+	case p.Output:
+		return "writeonly "
+	}
+	return "readonly "
+}
+
+// source Generated shader text, layouts holds image format qualifier of every image parameter
+func (k *ElementwiseKernel) source(layouts []string) string {
+	var s strings.Builder
+	buffers, images := 0, 0
+	for i, p := range k.params {
+		switch p.Kind {
+		case EScalar:
+			fmt.Fprintf(&s, "uniform %s %s;\n", p.Type, p.Name)
+		case EBuffer:
+			fmt.Fprintf(&s, "layout(std430, binding = %d) %sbuffer ew_%s_block {\n\t%s ew_%s[];\n};\n",
+				buffers, memoryAccess(p), p.Name, p.Type, p.Name)
+			buffers++
+		case EImage:
+			fmt.Fprintf(&s, "layout(%s, binding = %d) %suniform image2D ew_%s;\n", layouts[i], images, memoryAccess(p), p.Name)
+			images++
+		}
+	}
+	s.WriteString("uniform int ew_count;\n")
+	if k.preamble != "" {
+		s.WriteString(k.preamble + "\n")
+	}
+	fmt.Fprintf(&s, "layout(local_size_x = %d, local_size_y = 1, local_size_z = 1) in;\n", elementwiseGroup)
+	s.WriteString("void main() {\n")
+	fmt.Fprintf(&s, "\tint i = int((gl_WorkGroupID.y * gl_NumWorkGroups.x + gl_WorkGroupID.x) * %du + gl_LocalInvocationID.x);\n", elementwiseGroup)
+	s.WriteString("\tif (i >= ew_count) {\n\t\treturn;\n\t}\n")
+	for _, p := range k.params {
+		switch p.Kind {
+		case EBuffer:
+			if p.Input {
+				fmt.Fprintf(&s, "\t%s %s = ew_%s[i];\n", p.Type, p.Name, p.Name)
+			} else {
+				fmt.Fprintf(&s, "\t%s %s = %s(0);\n", p.Type, p.Name, p.Type)
+			}
+		case EImage:
+			fmt.Fprintf(&s, "\tivec2 ew_%s_coord = ivec2(i %% imageSize(ew_%s).x, i / imageSize(ew_%s).x);\n", p.Name, p.Name, p.Name)
+			if p.Input {
+				fmt.Fprintf(&s, "\tvec4 %s = imageLoad(ew_%s, ew_%s_coord);\n", p.Name, p.Name, p.Name)
+			} else {
+				fmt.Fprintf(&s, "\tvec4 %s = vec4(0.0);\n", p.Name)
+			}
+		}
+	}
+	body := k.body
+	if !strings.HasSuffix(body, ";") && !strings.HasSuffix(body, "}") {
+		body += ";"
+	}
+	s.WriteString("\t{\n\t\t" + body + "\n\t}\n")
+	for _, p := range k.params {
+		if !p.Output {
+			continue
+		}
+		if p.Kind == EImage {
+			fmt.Fprintf(&s, "\timageStore(ew_%s, ew_%s_coord, %s);\n", p.Name, p.Name, p.Name)
+		} else {
+			fmt.Fprintf(&s, "\tew_%s[i] = %s;\n", p.Name, p.Name)
+		}
+	}
+	s.WriteString("}\n")
+	return s.String()
+}
+
+// count Elements held by buffer or image argument
+func (k *ElementwiseKernel) count(p ElementwiseParam, arg any) (int, string, error) {
+	switch p.Kind {
+	case EBuffer:
+		buffer, ok := arg.(*GpuBuffer)
+		if !ok || buffer == nil {
+			return 0, "", fmt.Errorf("%s: argument %s must be *GpuBuffer, got %T", k.name, p.Name, arg)
+		}
+		return buffer.ByteSize() / elementSizes[p.Type], "", nil
+	case EImage:
+		texture, ok := arg.(*GpuTexture)
+		if !ok || texture == nil {
+			return 0, "", fmt.Errorf("%s: argument %s must be *GpuTexture, got %T", k.name, p.Name, arg)
+		}
+		//Kernel declares image2D, other targets can't be bound to it
+		if texture.sampler != gl.TEXTURE_2D {
+			return 0, "", errors.New(k.name + ": image " + p.Name + " must be 2D texture")
+		}
+		format, err := texture.TextureFormat()
+		if err != nil {
+			return 0, "", err
+		}
+		if !format.ImageLoadStore() || strings.HasSuffix(format.Layout, "i") {
+			return 0, "", errors.New(k.name + ": texture " + p.Name + " of type " + texture.Type().String() + " is not a float image format")
+		}
+		return texture.SizeX * texture.SizeY, format.Layout, nil
+	}
+	return 0, "", nil
+}
+
+// prepare Checking arguments and resolving element count, n <= 0 takes size of first output
+func (k *ElementwiseKernel) prepare(n int, args []any) (int, []string, error) {
+	if len(args) != len(k.params) {
+		return 0, nil, fmt.Errorf("%s: %d arguments for %d parameters", k.name, len(args), len(k.params))
+	}
+	layouts := make([]string, len(args))
+	counts := make([]int, len(args))
+	for i, p := range k.params {
+		if p.Kind == EScalar {
+			continue
+		}
+		count, layout, err := k.count(p, args[i])
+		if err != nil {
+			return 0, nil, err
+		}
+		counts[i], layouts[i] = count, layout
+		if n <= 0 && p.Output {
+			n = count
+		}
+	}
+	for i, p := range k.params {
+		if p.Kind != EScalar && counts[i] < n {
+			return 0, nil, fmt.Errorf("%s: %s holds %d elements, kernel runs over %d", k.name, p.Name, counts[i], n)
+		}
+	}
+	return n, layouts, nil
+}
+
+// setScalar Uniform of current program from Go value, must run on GL thread
+func (k *ElementwiseKernel) setScalar(p ElementwiseParam, value any) error {
+	location := k.c.GetUniformLocation(p.Name)
+	wrong := fmt.Errorf("%s: can't pass %T as %s %s", k.name, value, p.Type, p.Name)
+	var floats []float32
+	var ints []int32
+	switch v := value.(type) {
+	case float32:
+		floats = []float32{v}
+	case float64:
+		floats = []float32{float32(v)}
+	case int:
+		ints = []int32{int32(v)}
+	case int32:
+		ints = []int32{v}
+	case uint32:
+		ints = []int32{int32(v)}
+	case []float32:
+		floats = v
+	case []int32:
+		ints = v
+	case []int:
+		for _, x := range v {
+			ints = append(ints, int32(x))
+		}
+	default:
+		return wrong
+	}
+	size := scalarSizes[p.Type]
+	if strings.HasPrefix(p.Type, "vec") || p.Type == "float" {
+		for _, x := range ints {
+			floats = append(floats, float32(x))
+		}
+		if len(floats) != size {
+			return wrong
+		}
+		if location != -1 {
+			switch size {
+			case 1:
+				gl.Uniform1fv(location, 1, &floats[0])
+			case 2:
+				gl.Uniform2fv(location, 1, &floats[0])
+			case 3:
+				gl.Uniform3fv(location, 1, &floats[0])
+			case 4:
+				gl.Uniform4fv(location, 1, &floats[0])
+			}
+		}
+		return nil
+	}
+	if floats != nil || len(ints) != size {
+		return wrong
+	}
+	if location != -1 {
+		switch {
+		case p.Type == "uint":
+			gl.Uniform1ui(location, uint32(ints[0]))
+		case size == 1:
+			gl.Uniform1iv(location, 1, &ints[0])
+		case size == 2:
+			gl.Uniform2iv(location, 1, &ints[0])
+		case size == 3:
+			gl.Uniform3iv(location, 1, &ints[0])
+		case size == 4:
+			gl.Uniform4iv(location, 1, &ints[0])
+		}
+	}
+	return nil
+}
+
+// Source Generated shader for arguments, image formats are taken from textures
+func (k *ElementwiseKernel) Source(args ...any) (string, error) {
+	_, layouts, err := k.prepare(0, args)
+	if err != nil {
+		return "", err
+	}
+	return k.source(layouts), nil
+}
+
+// Run Running kernel over size of first output, arguments follow parameter order
+// Buffers are passed as *GpuBuffer, images as *GpuTexture, scalars as numbers or slices
+func (k *ElementwiseKernel) Run(args ...any) error {
+	return k.RunN(0, args...)
+}
+
+// RunN Running kernel over first n elements, every array argument must hold at least n
+func (k *ElementwiseKernel) RunN(n int, args ...any) error {
+	n, layouts, err := k.prepare(n, args)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	text := k.source(layouts)
+	return k.c.DoErr(func() error {
+		program, err := k.c.builtinProgram("elementwise "+text, text)
+		if err != nil {
+			return errors.New(k.name + ": " + err.Error())
+		}
+		k.c.UseProgram(program)
+		buffers, images := 0, 0
+		for i, p := range k.params {
+			switch p.Kind {
+			case EScalar:
+				if err := k.setScalar(p, args[i]); err != nil {
+					return err
+				}
+			case EBuffer:
+				args[i].(*GpuBuffer).SetBinding(buffers)
+				buffers++
+			case EImage:
+				if err := args[i].(*GpuTexture).SetBinding(images); err != nil {
+					return err
+				}
+				images++
+			}
+		}
+		k.c.setInt("ew_count", n)
+		groups := (n + elementwiseGroup - 1) / elementwiseGroup
+		if groups <= 65535 {
+			k.c.Realize(groups, 1, 1)
+		} else {
+			k.c.Realize(65535, (groups+65534)/65535, 1)
+		}
+		k.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT | gl.SHADER_IMAGE_ACCESS_BARRIER_BIT |
+			gl.BUFFER_UPDATE_BARRIER_BIT | gl.TEXTURE_UPDATE_BARRIER_BIT | gl.PIXEL_BUFFER_BARRIER_BIT)
+		CheckErr(k.name)
+		return nil
+	})
+}
@@ -0,0 +1,168 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+	"strings"
+)
+
+// Evaluator Fusing expression trees into single generated elementwise kernel
+type Evaluator struct {
+	c *gc.Computing
+}
+
+func New(c *gc.Computing) *Evaluator {
+	return &Evaluator{c: c}
+}
+
+type leaf struct {
+	buffer *gc.GpuBuffer
+	typ    Type
+}
+
+// generator Kernel parameters and body statements of fused expressions
+type generator struct {
+	params    []gc.ElementwiseParam
+	args      []any
+	leaves    map[leaf]string
+	names     map[*Expr]string
+	functions map[*Function]bool
+	preamble  strings.Builder
+	body      strings.Builder
+}
+
+// visit Emitting node once, shared subexpressions reuse their variable
+func (g *generator) visit(e *Expr) string {
+	if name, ok := g.names[e]; ok {
+		return name
+	}
+	var name string
+	switch {
+	case e.buffer != nil:
+		key := leaf{e.buffer, e.typ}
+		var ok bool
+		if name, ok = g.leaves[key]; !ok {
+			name = fmt.Sprintf("in%d", len(g.leaves))
+			g.leaves[key] = name
+			g.params = append(g.params, gc.ArgIn(name, string(e.typ)))
+			g.args = append(g.args, e.buffer)
+		}
+	case e.scalar != nil:
+		name = fmt.Sprintf("s%d", len(g.params))
+		g.params = append(g.params, gc.ArgScalar(name, string(e.typ)))
+		g.args = append(g.args, e.scalar)
+	case e.index:
+		name = "i"
+	default:
+		args := make([]any, len(e.args))
+		for i, a := range e.args {
+			args[i] = g.visit(a)
+		}
+		if e.fn != nil && !g.functions[e.fn] {
+			g.functions[e.fn] = true
+			g.preamble.WriteString(e.fn.Code + "\n")
+		}
+		name = fmt.Sprintf("t%d", len(g.names))
+		fmt.Fprintf(&g.body, "%s %s = "+e.op+";\n\t\t", append([]any{e.typ, name}, args...)...)
+	}
+	g.names[e] = name
+	return name
+}
+
+// Source Generated kernel preamble and body of expressions, for inspection
+func Source(es ...*Expr) (string, error) {
+	g, err := generate(es, nil)
+	if err != nil {
+		return "", err
+	}
+	return g.preamble.String() + g.body.String(), nil
+}
+
+// generate Building kernel writing expression j into output out<j>
+func generate(es []*Expr, outputs []*gc.GpuBuffer) (*generator, error) {
+	if len(es) == 0 {
+		return nil, errors.New("expr: nothing to evaluate")
+	}
+	g := &generator{leaves: make(map[leaf]string), names: make(map[*Expr]string), functions: make(map[*Function]bool)}
+	for _, e := range es {
+		if e.err != nil {
+			return nil, e.err
+		}
+		if e.typ.rank() == 0 {
+			return nil, errors.New("expr: can't store " + string(e.typ) + ", cast it to number first")
+		}
+		if e.length != es[0].length {
+			return nil, fmt.Errorf("expr: evaluated lengths %d and %d differ", es[0].length, e.length)
+		}
+	}
+	for j, e := range es {
+		name := g.visit(e)
+		fmt.Fprintf(&g.body, "out%d = %s;\n\t\t", j, name)
+	}
+	for j, e := range es {
+		g.params = append(g.params, gc.ArgOut(fmt.Sprintf("out%d", j), string(e.typ)))
+		if outputs != nil {
+			g.args = append(g.args, outputs[j])
+		}
+	}
+	return g, nil
+}
+
+// checkLength Evaluated expressions read buffers to take their length from
+func checkLength(es []*Expr) error {
+	if len(es) > 0 && es[0].err == nil && es[0].length == 0 {
+		return errors.New("expr: length is unknown, expression reads no buffer")
+	}
+	return nil
+}
+
+// EvalTo Running expressions in one pass, result j is written into dst[j]
+func (v *Evaluator) EvalTo(dst []*gc.GpuBuffer, es ...*Expr) error {
+	if len(dst) != len(es) {
+		return fmt.Errorf("expr: %d buffers for %d expressions", len(dst), len(es))
+	}
+	g, err := generate(es, dst)
+	if err != nil {
+		return err
+	}
+	if err := checkLength(es); err != nil {
+		return err
+	}
+	kernel, err := v.c.NewElementwise("expr", strings.TrimSpace(g.body.String()), g.params...)
+	if err != nil {
+		return err
+	}
+	return kernel.Preamble(g.preamble.String()).RunN(es[0].length, g.args...)
+}
+
+// Eval Running expressions in one pass into new buffers owned by caller
+func (v *Evaluator) Eval(es ...*Expr) ([]*gc.GpuBuffer, error) {
+	if _, err := generate(es, nil); err != nil {
+		return nil, err
+	}
+	if err := checkLength(es); err != nil {
+		return nil, err
+	}
+	dst := make([]*gc.GpuBuffer, len(es))
+	for j, e := range es {
+		dst[j] = v.c.NewBuffer()
+		gc.BufferAllocateBytes(dst[j], e.length*4, 1)
+	}
+	if err := v.EvalTo(dst, es...); err != nil {
+		for _, b := range dst {
+			b.Close()
+		}
+		return nil, err
+	}
+	return dst, nil
+}
+
+// Materialize Evaluating expression into buffer leaf, for results reused by many later expressions
+func (v *Evaluator) Materialize(e *Expr) (*Expr, error) {
+	dst, err := v.Eval(e)
+	if err != nil {
+		return nil, err
+	}
+	return Buffer(dst[0], e.typ, e.length), nil
+}
@@ -0,0 +1,268 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+)
+
+// Type GLSL type of expression value
+type Type string
+
+const (
+	Float Type = "float"
+	Int   Type = "int"
+	Uint  Type = "uint"
+	// Bool Result of comparisons, must be cast before evaluation into buffer
+	Bool Type = "bool"
+)
+
+// rank Promotion order of numeric types, bool is not numeric
+func (t Type) rank() int {
+	switch t {
+	case Int:
+		return 1
+	case Uint:
+		return 2
+	case Float:
+		return 3
+	}
+	return 0
+}
+
+// Expr Lazy node of elementwise expression tree, nothing runs until evaluation
+type Expr struct {
+	typ Type
+	// length Elements of buffer leaves below node, 0 when only scalars and index are used
+	length int
+	err    error
+	// op GLSL format of node with %s per argument, empty for leaves
+	op   string
+	args []*Expr
+	fn   *Function
+	// leaves
+	buffer *gc.GpuBuffer
+	scalar any
+	index  bool
+}
+
+// Function User GLSL function callable from expressions
+type Function struct {
+	Name   string
+	Result Type
+	Code   string
+}
+
+// Func Declaring GLSL function, code holds full definition named name
+func Func(name string, result Type, code string) *Function {
+	return &Function{Name: name, Result: result, Code: code}
+}
+
+// Buffer Leaf reading first n elements of buffer with type t
+func Buffer(buffer *gc.GpuBuffer, t Type, n int) *Expr {
+	e := &Expr{typ: t, length: n, buffer: buffer}
+	if t.rank() == 0 {
+		e.err = errors.New("expr: buffer of " + string(t) + " is not supported")
+	} else if n <= 0 || buffer.ByteSize() < n*4 {
+		e.err = fmt.Errorf("expr: buffer of %d bytes can't hold %d elements", buffer.ByteSize(), n)
+	}
+	return e
+}
+
+// Scalar Uniform leaf, value is float32, int32 or uint32
+func Scalar(value any) *Expr {
+	switch value.(type) {
+	case float32:
+		return &Expr{typ: Float, scalar: value}
+	case int32:
+		return &Expr{typ: Int, scalar: value}
+	case uint32:
+		return &Expr{typ: Uint, scalar: value}
+	}
+	return &Expr{err: fmt.Errorf("expr: scalar of %T is not supported", value)}
+}
+
+// F Float scalar
+func F(value float32) *Expr {
+	return Scalar(value)
+}
+
+// Index Element index as int
+func Index() *Expr {
+	return &Expr{typ: Int, index: true}
+}
+
+// Type Value type of expression
+func (e *Expr) Type() Type {
+	return e.typ
+}
+
+// Len Elements produced by evaluation, 0 when expression has no buffer
+func (e *Expr) Len() int {
+	return e.length
+}
+
+// Err First error found while building expression
+func (e *Expr) Err() error {
+	return e.err
+}
+
+// Buffer Storage of buffer leaf, nil for other nodes
+func (e *Expr) Buffer() *gc.GpuBuffer {
+	return e.buffer
+}
+
+// node Combining arguments, first argument error or length mismatch is kept
+func node(t Type, op string, args ...*Expr) *Expr {
+	e := &Expr{typ: t, op: op, args: args}
+	for _, a := range args {
+		if a.err != nil {
+			e.err = a.err
+			return e
+		}
+		if a.length != 0 {
+			if e.length != 0 && e.length != a.length {
+				e.err = fmt.Errorf("expr: length %d doesn't match %d", a.length, e.length)
+				return e
+			}
+			e.length = a.length
+		}
+	}
+	return e
+}
+
+func failed(err error, args ...*Expr) *Expr {
+	for _, a := range args {
+		if a.err != nil {
+			return &Expr{err: a.err}
+		}
+	}
+	return &Expr{err: err}
+}
+
+// Cast Converting value to type t
+func (e *Expr) Cast(t Type) *Expr {
+	if e.typ == t {
+		return e
+	}
+	if t.rank() == 0 && t != Bool {
+		return failed(errors.New("expr: cast to unknown type "+string(t)), e)
+	}
+	return node(t, string(t)+"(%s)", e)
+}
+
+// promote Casting numeric operands to common type
+func promote(name string, a, b *Expr) (*Expr, *Expr, error) {
+	if a.err != nil || b.err != nil {
+		return a, b, nil
+	}
+	if a.typ.rank() == 0 || b.typ.rank() == 0 {
+		return nil, nil, errors.New("expr: " + name + " of " + string(a.typ) + " and " + string(b.typ))
+	}
+	if a.typ.rank() < b.typ.rank() {
+		a = a.Cast(b.typ)
+	} else {
+		b = b.Cast(a.typ)
+	}
+	return a, b, nil
+}
+
+func binary(name, op string, a, b *Expr, compare bool) *Expr {
+	a, b, err := promote(name, a, b)
+	if err != nil {
+		return failed(err)
+	}
+	t := a.typ
+	if compare {
+		t = Bool
+	}
+	return node(t, op, a, b)
+}
+
+// Add Arithmetic and comparisons cast operands to common type, int < uint < float
+func (e *Expr) Add(o *Expr) *Expr { return binary("add", "(%s + %s)", e, o, false) }
+func (e *Expr) Sub(o *Expr) *Expr { return binary("sub", "(%s - %s)", e, o, false) }
+func (e *Expr) Mul(o *Expr) *Expr { return binary("mul", "(%s * %s)", e, o, false) }
+func (e *Expr) Div(o *Expr) *Expr { return binary("div", "(%s / %s)", e, o, false) }
+func (e *Expr) Min(o *Expr) *Expr { return binary("min", "min(%s, %s)", e, o, false) }
+func (e *Expr) Max(o *Expr) *Expr { return binary("max", "max(%s, %s)", e, o, false) }
+
+// Pow Float power
+func (e *Expr) Pow(o *Expr) *Expr {
+	return binary("pow", "pow(%s, %s)", e.Cast(Float), o.Cast(Float), false)
+}
+
+func (e *Expr) Less(o *Expr) *Expr      { return binary("less", "(%s < %s)", e, o, true) }
+func (e *Expr) LessEqual(o *Expr) *Expr { return binary("less", "(%s <= %s)", e, o, true) }
+func (e *Expr) Greater(o *Expr) *Expr   { return binary("greater", "(%s > %s)", e, o, true) }
+func (e *Expr) Equal(o *Expr) *Expr     { return binary("equal", "(%s == %s)", e, o, true) }
+
+// Neg Negated value, uint wraps around
+func (e *Expr) Neg() *Expr {
+	if e.typ.rank() == 0 {
+		return failed(errors.New("expr: negation of "+string(e.typ)), e)
+	}
+	return node(e.typ, "(-%s)", e)
+}
+
+// Abs Absolute value, uint is kept as is
+func (e *Expr) Abs() *Expr {
+	if e.typ == Uint {
+		return e
+	}
+	if e.typ.rank() == 0 {
+		return failed(errors.New("expr: abs of "+string(e.typ)), e)
+	}
+	return node(e.typ, "abs(%s)", e)
+}
+
+// float Float function of single argument
+func (e *Expr) float(name string) *Expr {
+	if e.err == nil && e.typ.rank() == 0 {
+		return failed(errors.New("expr: " + name + " of " + string(e.typ)))
+	}
+	return node(Float, name+"(%s)", e.Cast(Float))
+}
+
+// Exp Float functions cast int and uint operands to float
+func (e *Expr) Exp() *Expr   { return e.float("exp") }
+func (e *Expr) Log() *Expr   { return e.float("log") }
+func (e *Expr) Sqrt() *Expr  { return e.float("sqrt") }
+func (e *Expr) Sin() *Expr   { return e.float("sin") }
+func (e *Expr) Cos() *Expr   { return e.float("cos") }
+func (e *Expr) Tanh() *Expr  { return e.float("tanh") }
+func (e *Expr) Floor() *Expr { return e.float("floor") }
+
+// Not Logical negation of bool
+func (e *Expr) Not() *Expr {
+	if e.err == nil && e.typ != Bool {
+		return failed(errors.New("expr: not of " + string(e.typ)))
+	}
+	return node(Bool, "(!%s)", e)
+}
+
+// Select Choosing a where cond holds and b elsewhere
+func Select(cond, a, b *Expr) *Expr {
+	if cond.err == nil && cond.typ != Bool {
+		return failed(errors.New("expr: select condition of " + string(cond.typ)))
+	}
+	a, b, err := promote("select", a, b)
+	if err != nil {
+		return failed(err, cond)
+	}
+	return node(a.typ, "(%s ? %s : %s)", cond, a, b)
+}
+
+// Call Applying user function to arguments, argument types are not checked before compilation
+func Call(fn *Function, args ...*Expr) *Expr {
+	op := fn.Name + "("
+	for i := range args {
+		if i > 0 {
+			op += ", "
+		}
+		op += "%s"
+	}
+	e := node(fn.Result, op+")", args...)
+	e.fn = fn
+	return e
+}
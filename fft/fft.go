@@ -0,0 +1,338 @@
+// Package fft Batched 1D and 2D FFT of complex float32 buffers
+package fft
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/gl/all-core/gl"
+	"math"
+)
+
+// stageKernel Stockham stage of any radix, every invocation computes one output element
+const stageKernel = `
+layout(std430, binding = 0) readonly buffer fftInput {
+	vec2 inputValues[];
+};
+layout(std430, binding = 1) writeonly buffer fftOutput {
+	vec2 outputValues[];
+};
+layout(std430, binding = 2) readonly buffer fftTwiddles {
+	vec2 twiddles[];
+};
+uniform int n;
+uniform int radix;
+uniform int stride;
+uniform int batches;
+uniform int elementStride;
+uniform int batchStride;
+uniform int invert;
+uniform float scale;
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+
+vec2 cmul(vec2 a, vec2 b) {
+	return vec2(a.x * b.x - a.y * b.y, a.x * b.y + a.y * b.x);
+}
+
+void main() {
+	uint id = (gl_WorkGroupID.y * gl_NumWorkGroups.x + gl_WorkGroupID.x) * 256u + gl_LocalInvocationID.x;
+	if (id >= uint(n * batches)) {
+		return;
+	}
+	int base = int(id) / n * batchStride;
+	int o = int(id) % n;
+	int k = o % stride;
+	int q = (o / stride) % radix;
+	int j = o / (stride * radix) * stride + k;
+	int m = n / radix;
+	int increment = (k + q * stride) * (n / (stride * radix));
+	int e = 0;
+	vec2 sum = vec2(0.0);
+	for (int r = 0; r < radix; r++) {
+		vec2 w = twiddles[e];
+		if (invert != 0) {
+			w.y = -w.y;
+		}
+		sum += cmul(inputValues[base + (j + r * m) * elementStride], w);
+		e = (e + increment) % n;
+	}
+	outputValues[base + o * elementStride] = sum * scale;
+}
+`
+
+const realKernel = `
+layout(std430, binding = 0) readonly buffer fftInput {
+	float inputValues[];
+};
+layout(std430, binding = 1) writeonly buffer fftOutput {
+	vec2 outputValues[];
+};
+uniform int count;
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+	uint id = (gl_WorkGroupID.y * gl_NumWorkGroups.x + gl_WorkGroupID.x) * 256u + gl_LocalInvocationID.x;
+	if (id < uint(count)) {
+		outputValues[id] = vec2(inputValues[id], 0.0);
+	}
+}
+`
+
+const complexKernel = `
+layout(std430, binding = 0) readonly buffer fftInput {
+	vec2 inputValues[];
+};
+layout(std430, binding = 1) writeonly buffer fftOutput {
+	float outputValues[];
+};
+uniform int count;
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+	uint id = (gl_WorkGroupID.y * gl_NumWorkGroups.x + gl_WorkGroupID.x) * 256u + gl_LocalInvocationID.x;
+	if (id < uint(count)) {
+		outputValues[id] = inputValues[id].x;
+	}
+}
+`
+
+// FFT Programs, twiddle tables by size and scratch buffers of one Computing
+// Transforms use storage bindings 0 to 2 and change current program
+type FFT struct {
+	c        *gc.Computing
+	programs map[string]int
+	twiddles map[int]*gc.GpuBuffer
+	scratch  [3]*gc.GpuBuffer
+}
+
+func New(c *gc.Computing) *FFT {
+	return &FFT{c: c, programs: make(map[string]int), twiddles: make(map[int]*gc.GpuBuffer)}
+}
+
+// Close Deleting programs, twiddle tables and scratch buffers
+func (f *FFT) Close() {
+	for _, id := range f.programs {
+		f.c.DeleteProgram(id)
+	}
+	for _, b := range f.twiddles {
+		b.Close()
+	}
+	for i, b := range f.scratch {
+		if b != nil {
+			b.Close()
+			f.scratch[i] = nil
+		}
+	}
+	f.programs = make(map[string]int)
+	f.twiddles = make(map[int]*gc.GpuBuffer)
+}
+
+// Factors Radices of size, fours first, primes above 7 are transformed directly
+func Factors(n int) []int {
+	factors := make([]int, 0)
+	for n%4 == 0 {
+		factors = append(factors, 4)
+		n /= 4
+	}
+	for p := 2; n > 1; p++ {
+		for n%p == 0 {
+			factors = append(factors, p)
+			n /= p
+		}
+	}
+	return factors
+}
+
+func (f *FFT) program(text string) (int, error) {
+	if id, ok := f.programs[text]; ok {
+		return id, nil
+	}
+	id, err := f.c.LoadProgram(text)
+	if err != nil {
+		return 0, errors.New("fft: " + err.Error())
+	}
+	f.programs[text] = id
+	return id, nil
+}
+
+// twiddle Table of exp(-2 pi i m / n), computed in float64 once per size
+func (f *FFT) twiddle(n int) *gc.GpuBuffer {
+	if b := f.twiddles[n]; b != nil {
+		return b
+	}
+	table := make([]gc.Vec2, n)
+	for m := range table {
+		sin, cos := math.Sincos(-2 * math.Pi * float64(m) / float64(n))
+		table[m] = gc.Vec2{X: float32(cos), Y: float32(sin)}
+	}
+	b := f.c.NewBufferV(gc.BStaticRead, gc.BStorage)
+	gc.BufferLoad(b, table)
+	f.twiddles[n] = b
+	return b
+}
+
+func (f *FFT) buffer(i, bytes int) *gc.GpuBuffer {
+	b := f.scratch[i]
+	if b == nil {
+		b = f.c.NewBufferV(gc.BDynamicCopy, gc.BStorage)
+		f.scratch[i] = b
+	} else if b.ByteSize() >= bytes {
+		return b
+	}
+	gc.BufferAllocateBytes(b, bytes, 1)
+	return b
+}
+
+func (f *FFT) dispatch(count int) {
+	groups := (count + 255) / 256
+	if groups <= 65535 {
+		f.c.Realize(groups, 1, 1)
+	} else {
+		f.c.Realize(65535, (groups+65534)/65535, 1)
+	}
+	f.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+}
+
+func check(n, batches int, buffers ...*gc.GpuBuffer) error {
+	if n <= 0 || batches <= 0 {
+		return fmt.Errorf("fft: size %d with %d batches", n, batches)
+	}
+	for _, b := range buffers {
+		if b.ByteSize() < n*batches*8 {
+			return fmt.Errorf("fft: %d transforms of %d need %d bytes, buffer has %d", batches, n, n*batches*8, b.ByteSize())
+		}
+	}
+	return nil
+}
+
+// transform Running stages from src to dst, strides select rows or columns of 2D data
+// Inverse is scaled by 1/n, src may equal dst
+func (f *FFT) transform(src, dst *gc.GpuBuffer, n, batches, elementStride, batchStride int, inverse bool) error {
+	program, err := f.program(stageKernel)
+	if err != nil {
+		return err
+	}
+	radices := Factors(n)
+	if len(radices) == 0 || (len(radices) == 1 && src == dst) {
+		//Radix 1 stage copies, keeping stage input and output apart
+		radices = append(radices, 1)
+	}
+	f.c.UseProgram(program)
+	f.twiddle(n).SetBinding(2)
+	f.c.SetInt("n", n)
+	f.c.SetInt("batches", batches)
+	f.c.SetInt("elementStride", elementStride)
+	f.c.SetInt("batchStride", batchStride)
+	flag := 0
+	if inverse {
+		flag = 1
+	}
+	f.c.SetInt("invert", flag)
+	stride := 1
+	from := src
+	for s, radix := range radices {
+		to := dst
+		scale := float32(1)
+		if s < len(radices)-1 {
+			to = f.buffer(s%2, n*batches*8)
+		} else if inverse {
+			scale = 1 / float32(n)
+		}
+		from.SetBinding(0)
+		to.SetBinding(1)
+		f.c.SetInt("radix", radix)
+		f.c.SetInt("stride", stride)
+		f.c.SetFloat32("scale", scale)
+		f.dispatch(n * batches)
+		stride *= radix
+		from = to
+	}
+	return nil
+}
+
+// Forward Transforming batches of n contiguous complex values, input may equal output
+func (f *FFT) Forward(input, output *gc.GpuBuffer, n, batches int) error {
+	if err := check(n, batches, input, output); err != nil {
+		return err
+	}
+	return f.c.DoErr(func() error {
+		return f.transform(input, output, n, batches, 1, n, false)
+	})
+}
+
+// Inverse Inverse of Forward including 1/n scaling
+func (f *FFT) Inverse(input, output *gc.GpuBuffer, n, batches int) error {
+	if err := check(n, batches, input, output); err != nil {
+		return err
+	}
+	return f.c.DoErr(func() error {
+		return f.transform(input, output, n, batches, 1, n, true)
+	})
+}
+
+func (f *FFT) transform2D(input, output *gc.GpuBuffer, width, height int, inverse bool) error {
+	if err := check(width, height, input, output); err != nil {
+		return err
+	}
+	return f.c.DoErr(func() error {
+		if err := f.transform(input, output, width, height, 1, width, inverse); err != nil {
+			return err
+		}
+		return f.transform(output, output, height, width, width, 1, inverse)
+	})
+}
+
+// Forward2D Transforming row major width by height complex values
+func (f *FFT) Forward2D(input, output *gc.GpuBuffer, width, height int) error {
+	return f.transform2D(input, output, width, height, false)
+}
+
+// Inverse2D Inverse of Forward2D including 1/(width height) scaling
+func (f *FFT) Inverse2D(input, output *gc.GpuBuffer, width, height int) error {
+	return f.transform2D(input, output, width, height, true)
+}
+
+// convert Running packing kernel over count elements
+func (f *FFT) convert(text string, input, output *gc.GpuBuffer, count int) error {
+	program, err := f.program(text)
+	if err != nil {
+		return err
+	}
+	f.c.UseProgram(program)
+	input.SetBinding(0)
+	output.SetBinding(1)
+	f.c.SetInt("count", count)
+	f.dispatch(count)
+	return nil
+}
+
+// ForwardReal Transforming batches of n float32 values into full complex spectra
+func (f *FFT) ForwardReal(real, output *gc.GpuBuffer, n, batches int) error {
+	if err := check(n, batches, output); err != nil {
+		return err
+	}
+	if real.ByteSize() < n*batches*4 {
+		return fmt.Errorf("fft: %d real values need %d bytes, buffer has %d", n*batches, n*batches*4, real.ByteSize())
+	}
+	return f.c.DoErr(func() error {
+		if err := f.convert(realKernel, real, output, n*batches); err != nil {
+			return err
+		}
+		return f.transform(output, output, n, batches, 1, n, false)
+	})
+}
+
+// InverseReal Inverse transform keeping real parts, imaginary parts of real signals are rounding noise
+func (f *FFT) InverseReal(input, real *gc.GpuBuffer, n, batches int) error {
+	if err := check(n, batches, input); err != nil {
+		return err
+	}
+	if real.ByteSize() < n*batches*4 {
+		return fmt.Errorf("fft: %d real values need %d bytes, buffer has %d", n*batches, n*batches*4, real.ByteSize())
+	}
+	return f.c.DoErr(func() error {
+		complex := f.buffer(2, n*batches*8)
+		if err := f.transform(input, complex, n, batches, 1, n, true); err != nil {
+			return err
+		}
+		return f.convert(complexKernel, complex, real, n*batches)
+	})
+}
@@ -0,0 +1,47 @@
+package fft
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"math"
+	"math/cmplx"
+)
+
+// DFT Reference transform in float64, inverse is scaled by 1/n
+func DFT(data []gc.Vec2, inverse bool) []gc.Vec2 {
+	n := len(data)
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	out := make([]gc.Vec2, n)
+	for k := range out {
+		sum := complex(0, 0)
+		for j, v := range data {
+			angle := sign * 2 * math.Pi * float64(j*k%n) / float64(n)
+			sum += complex(float64(v.X), float64(v.Y)) * cmplx.Exp(complex(0, angle))
+		}
+		if inverse {
+			sum /= complex(float64(n), 0)
+		}
+		out[k] = gc.Vec2{X: float32(real(sum)), Y: float32(imag(sum))}
+	}
+	return out
+}
+
+// DFT2D Reference of 2D transform on row major width by height data
+func DFT2D(data []gc.Vec2, width, height int, inverse bool) []gc.Vec2 {
+	out := make([]gc.Vec2, len(data))
+	for y := 0; y < height; y++ {
+		copy(out[y*width:], DFT(data[y*width:(y+1)*width], inverse))
+	}
+	column := make([]gc.Vec2, height)
+	for x := 0; x < width; x++ {
+		for y := range column {
+			column[y] = out[y*width+x]
+		}
+		for y, v := range DFT(column, inverse) {
+			out[y*width+x] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,242 @@
+package gocompute
+
+import (
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"runtime"
+	"sync"
+)
+
+// glThread Locked OS thread executing all GL commands of Computing
+type glThread struct {
+	commands chan *task
+	// id OS thread owning context, recorded once after locking
+	id   uint64
+	stop sync.Once
+	// mutex Orders sends against stop, nothing is queued after closed is set
+	mutex   sync.RWMutex
+	closed  bool
+	stopped chan struct{}
+}
+
+// task Queued commands with fence signalled after them
+type task struct {
+	commands []func()
+	fence    *Fence
+}
+
+var errThreadStopped = errors.New("gocompute: GL thread stopped")
+
+// Fence Completion of asynchronously submitted commands
+type Fence struct {
+	done  chan struct{}
+	panic interface{}
+}
+
+// Done Channel closed after commands finished
+func (f *Fence) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait Blocking until commands finished, panic of command is repeated in caller goroutine
+func (f *Fence) Wait() {
+	<-f.done
+	if f.panic != nil {
+		panic(f.panic)
+	}
+}
+
+// Batch Commands executed on GL thread as one unit, no other goroutine interleaves between them
+type Batch struct {
+	c        *Computing
+	commands []func()
+}
+
+// execute Running commands and releasing fence, panic is kept for Wait
+func (t *task) execute() {
+	defer close(t.fence.done)
+	defer func() {
+		t.fence.panic = recover()
+	}()
+	for _, f := range t.commands {
+		f()
+	}
+}
+
+// fail Releasing fence of task that will never run
+func (t *task) fail() {
+	t.fence.panic = errThreadStopped
+	close(t.fence.done)
+}
+
+// NewComputingThread Creating Computing owning locked OS thread
+// init is executed on that thread and should create context and make it current
+func NewComputingThread(init func() error) (*Computing, error) {
+	c, err := NewComputing()
+	if err != nil {
+		return nil, err
+	}
+	th := &glThread{commands: make(chan *task, 64), stopped: make(chan struct{})}
+	result := make(chan error)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		th.id = currentThread()
+		if init != nil {
+			if err := init(); err != nil {
+				result <- err
+				return
+			}
+		}
+		result <- nil
+		for {
+			select {
+			case t := <-th.commands:
+				//Stop may have won the race with this receive
+				select {
+				case <-th.stopped:
+					t.fail()
+				default:
+					t.execute()
+				}
+			case <-th.stopped:
+				//stopThread fails whatever is left in queue
+				return
+			}
+		}
+	}()
+	if err = <-result; err != nil {
+		return nil, err
+	}
+	c.thread = th
+	return c, nil
+}
+
+// onThread Caller is GL thread of c and is allowed to issue GL calls directly
+func (c *Computing) onThread() bool {
+	return c == nil || c.thread == nil || c.thread.id == currentThread()
+}
+
+// onGroupThread Caller is GL thread of any share group member, objects of c are valid there
+func (c *Computing) onGroupThread() bool {
+	if c.onThread() {
+		return true
+	}
+	return c.group != nil && c.group.contains(currentThread())
+}
+
+// bound Computing whose context is current on calling group thread, c itself on own thread
+func (c *Computing) bound() *Computing {
+	if c.onThread() {
+		return c
+	}
+	return c.group.member(currentThread())
+}
+
+// run Executing f on GL thread and waiting for it, inline without thread or when already on it
+func (c *Computing) run(f func()) {
+	if c.onThread() {
+		f()
+		return
+	}
+	c.submit([]func(){f}).Wait()
+}
+
+// runShared Same as run for buffer and texture commands, which also run inline on share group threads
+// Bindings made that way affect context of calling thread
+func (c *Computing) runShared(f func()) {
+	if c.onGroupThread() {
+		f()
+		return
+	}
+	c.submit([]func(){f}).Wait()
+}
+
+func (c *Computing) submit(commands []func()) *Fence {
+	t := &task{commands: commands, fence: &Fence{done: make(chan struct{})}}
+	if c.onThread() {
+		t.execute()
+		return t.fence
+	}
+	th := c.thread
+	th.mutex.RLock()
+	defer th.mutex.RUnlock()
+	if th.closed {
+		t.fail()
+		return t.fence
+	}
+	select {
+	case th.commands <- t:
+	case <-th.stopped:
+		t.fail()
+	}
+	return t.fence
+}
+
+// Do Executing f on GL thread and waiting for it, f may call any Computing, buffer or texture methods
+func (c *Computing) Do(f func()) {
+	c.run(f)
+}
+
+// DoErr Same as Do for functions returning error
+func (c *Computing) DoErr(f func() error) error {
+	var err error
+	c.run(func() {
+		err = f()
+	})
+	return err
+}
+
+// Submit Queueing f on GL thread without waiting
+func (c *Computing) Submit(f func()) *Fence {
+	return c.submit([]func(){f})
+}
+
+// Finish Waiting for all previously queued commands and GPU work
+func (c *Computing) Finish() {
+	c.run(gl.Finish)
+}
+
+// NewBatch Collecting commands for single submission
+func (c *Computing) NewBatch() *Batch {
+	return &Batch{c: c}
+}
+
+func (b *Batch) Add(f func()) *Batch {
+	b.commands = append(b.commands, f)
+	return b
+}
+
+// Submit Queueing collected commands without waiting, batch is reset for reuse
+func (b *Batch) Submit() *Fence {
+	commands := b.commands
+	b.commands = nil
+	return b.c.submit(commands)
+}
+
+// stopThread Finishing queued commands and releasing OS thread
+// Commands submitted concurrently with stop fail with GL thread stopped error
+func (c *Computing) stopThread() {
+	th := c.thread
+	if th == nil {
+		return
+	}
+	th.stop.Do(func() {
+		if !c.onThread() {
+			c.submit([]func(){func() {}}).Wait()
+		}
+		//Senders blocked on full queue give up, then no one can send anymore
+		close(th.stopped)
+		th.mutex.Lock()
+		th.closed = true
+		th.mutex.Unlock()
+		for {
+			select {
+			case t := <-th.commands:
+				t.fail()
+			default:
+				return
+			}
+		}
+	})
+}
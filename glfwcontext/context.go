@@ -0,0 +1,69 @@
+// Package glfwcontext Hidden window GLFW contexts for gocompute.Computing
+package glfwcontext
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"sync"
+)
+
+var initOnce sync.Once
+var initErr error
+
+// Context Hidden 1x1 window holding 4.3 core context
+// GLFW requires New, Share and Destroy to be called from main thread on some platforms
+type Context struct {
+	window *glfw.Window
+}
+
+// New Creating standalone context, glfw is initialized on first call
+func New() (*Context, error) {
+	return create(nil)
+}
+
+func create(share *glfw.Window) (*Context, error) {
+	initOnce.Do(func() {
+		initErr = glfw.Init()
+	})
+	if initErr != nil {
+		return nil, initErr
+	}
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, 1)
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	window, err := glfw.CreateWindow(1, 1, "gocompute", nil, share)
+	if err != nil {
+		return nil, err
+	}
+	return &Context{window}, nil
+}
+
+// NewComputing Creating Computing owning new context
+func NewComputing() (*gc.Computing, error) {
+	ctx, err := New()
+	if err != nil {
+		return nil, err
+	}
+	return gc.NewComputingContext(ctx)
+}
+
+func (c *Context) MakeCurrent() error {
+	c.window.MakeContextCurrent()
+	return nil
+}
+
+func (c *Context) ReleaseCurrent() error {
+	glfw.DetachCurrentContext()
+	return nil
+}
+
+// Share Creating context in share group of c
+func (c *Context) Share() (gc.Context, error) {
+	return create(c.window)
+}
+
+func (c *Context) Destroy() {
+	c.window.Destroy()
+}
@@ -0,0 +1,542 @@
+package gocompute
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-gl/gl/all-core/gl"
+	"strings"
+)
+
+// Graph Kernels connected by declared buffer and texture dependencies
+// Compile orders nodes, plans memory barriers and shares storage of transient resources with disjoint lifetimes
+type Graph struct {
+	c         *Computing
+	nodes     []*GraphNode
+	resources []*GraphResource
+	names     map[string]*GraphResource
+	plan      []graphStep
+	physical  []*graphPhysical
+	// barrier before host reads of outputs
+	finalBarrier uint32
+	compiled     bool
+}
+
+// GraphResource Buffer or texture used by graph nodes
+// External resources are bound by caller, transient resources are owned by graph
+type GraphResource struct {
+	g        *Graph
+	name     string
+	texture  bool
+	external bool
+	keep     bool
+	// transient buffer descriptor
+	bytes int
+	// transient texture descriptor
+	texType TextureType
+	channels,
+	x, y, z int
+	producer *GraphNode
+	buffer   *GpuBuffer
+	tex      *GpuTexture
+	physical *graphPhysical
+	first    int
+	last     int
+}
+
+// GraphNode Single dispatch of program
+type GraphNode struct {
+	g        *Graph
+	name     string
+	program  int
+	inputs   []graphBinding
+	outputs  []graphBinding
+	uniforms []func(c *Computing)
+	size     func() (int, int, int)
+	threads  bool
+	index    int
+}
+
+type graphBinding struct {
+	binding  int
+	resource *GraphResource
+}
+
+type graphStep struct {
+	node    *GraphNode
+	barrier uint32
+}
+
+type graphPhysical struct {
+	buffer  *GpuBuffer
+	texture *GpuTexture
+	key     string
+	lastUse int
+}
+
+func (c *Computing) NewGraph() *Graph {
+	return &Graph{c: c, names: make(map[string]*GraphResource)}
+}
+
+func (g *Graph) addResource(r *GraphResource) *GraphResource {
+	if old, ok := g.names[r.name]; ok {
+		return old
+	}
+	r.g = g
+	g.names[r.name] = r
+	g.resources = append(g.resources, r)
+	g.compiled = false
+	return r
+}
+
+// Buffer External buffer slot, bind it with BindBuffer before Execute
+func (g *Graph) Buffer(name string) *GraphResource {
+	return g.addResource(&GraphResource{name: name, external: true})
+}
+
+// Texture External texture slot, bind it with BindTexture before Execute
+func (g *Graph) Texture(name string) *GraphResource {
+	return g.addResource(&GraphResource{name: name, texture: true, external: true})
+}
+
+// TransientBuffer Graph owned buffer of bytes size
+func (g *Graph) TransientBuffer(name string, bytes int) *GraphResource {
+	return g.addResource(&GraphResource{name: name, bytes: bytes})
+}
+
+// TransientTexture Graph owned texture, y and z of 1 give 1D and 2D textures
+func (g *Graph) TransientTexture(name string, texType TextureType, channels, x, y, z int) *GraphResource {
+	return g.addResource(&GraphResource{name: name, texture: true, texType: texType, channels: channels, x: x, y: y, z: z})
+}
+
+// Resource Declared resource by name, nil when missing
+func (g *Graph) Resource(name string) *GraphResource {
+	return g.names[name]
+}
+
+// BindBuffer Binding caller buffer to external slot, allowed between executions
+func (g *Graph) BindBuffer(name string, buffer *GpuBuffer) error {
+	r := g.names[name]
+	if r == nil || !r.external || r.texture {
+		return errors.New("Graph: " + name + " is not an external buffer")
+	}
+	r.buffer = buffer
+	return nil
+}
+
+// BindTexture Binding caller texture to external slot, allowed between executions
+func (g *Graph) BindTexture(name string, texture *GpuTexture) error {
+	r := g.names[name]
+	if r == nil || !r.external || !r.texture {
+		return errors.New("Graph: " + name + " is not an external texture")
+	}
+	r.tex = texture
+	return nil
+}
+
+// Keep Transient resource stays valid after Execute and is never shared with other resources
+func (r *GraphResource) Keep() *GraphResource {
+	r.keep = true
+	r.g.compiled = false
+	return r
+}
+
+func (r *GraphResource) Name() string {
+	return r.name
+}
+
+// Buffer Current buffer of resource, transient buffers exist after Compile
+func (r *GraphResource) Buffer() *GpuBuffer {
+	if r.physical != nil {
+		return r.physical.buffer
+	}
+	return r.buffer
+}
+
+// Texture Current texture of resource, transient textures exist after Compile
+func (r *GraphResource) Texture() *GpuTexture {
+	if r.physical != nil {
+		return r.physical.texture
+	}
+	return r.tex
+}
+
+func (r *GraphResource) key() string {
+	if r.texture {
+		return fmt.Sprint("t", r.texType, r.channels, r.x, r.y, r.z)
+	}
+	return fmt.Sprint("b", r.bytes)
+}
+
+func (r *GraphResource) barrierBit() uint32 {
+	if r.texture {
+		return gl.SHADER_IMAGE_ACCESS_BARRIER_BIT
+	}
+	return gl.SHADER_STORAGE_BARRIER_BIT
+}
+
+// AddNode Adding dispatch of loaded program
+func (g *Graph) AddNode(name string, program int) *GraphNode {
+	n := &GraphNode{g: g, name: name, program: program}
+	g.nodes = append(g.nodes, n)
+	g.compiled = false
+	return n
+}
+
+// Input Resource read by node at binding
+func (n *GraphNode) Input(binding int, r *GraphResource) *GraphNode {
+	n.inputs = append(n.inputs, graphBinding{binding, r})
+	n.g.compiled = false
+	return n
+}
+
+// Output Resource written by node at binding, every resource has at most one producer
+func (n *GraphNode) Output(binding int, r *GraphResource) *GraphNode {
+	n.outputs = append(n.outputs, graphBinding{binding, r})
+	n.g.compiled = false
+	return n
+}
+
+func (n *GraphNode) SetInt(name string, input ...int) *GraphNode {
+	n.uniforms = append(n.uniforms, func(c *Computing) {
+		c.SetInt(name, input...)
+	})
+	return n
+}
+
+func (n *GraphNode) SetFloat32(name string, input ...float32) *GraphNode {
+	n.uniforms = append(n.uniforms, func(c *Computing) {
+		c.SetFloat32(name, input...)
+	})
+	return n
+}
+
+// Uniform Custom uniform setter called after program is used
+func (n *GraphNode) Uniform(set func(c *Computing)) *GraphNode {
+	n.uniforms = append(n.uniforms, set)
+	return n
+}
+
+// Dispatch Work group counts of node
+func (n *GraphNode) Dispatch(x, y, z int) *GraphNode {
+	return n.DispatchFunc(func() (int, int, int) {
+		return x, y, z
+	})
+}
+
+// DispatchFunc Work group counts evaluated on each execution
+func (n *GraphNode) DispatchFunc(size func() (int, int, int)) *GraphNode {
+	n.size = size
+	n.threads = false
+	return n
+}
+
+// DispatchThreads Invocation counts, divided by program local size rounding up
+func (n *GraphNode) DispatchThreads(x, y, z int) *GraphNode {
+	n.size = func() (int, int, int) {
+		return x, y, z
+	}
+	n.threads = true
+	return n
+}
+
+// Compile Ordering nodes and planning barriers and transient storage
+func (g *Graph) Compile() (err error) {
+	g.c.run(func() {
+		err = g.compile()
+	})
+	return err
+}
+
+func (g *Graph) compile() error {
+	for _, r := range g.resources {
+		r.producer = nil
+		r.physical = nil
+		r.first, r.last = -1, -1
+	}
+	for _, n := range g.nodes {
+		if n.size == nil {
+			return errors.New("Graph: node " + n.name + " has no dispatch size")
+		}
+		for _, out := range n.outputs {
+			if out.resource.producer != nil && out.resource.producer != n {
+				return errors.New("Graph: " + out.resource.name + " is written by " + out.resource.producer.name + " and " + n.name)
+			}
+			out.resource.producer = n
+		}
+	}
+	//Kahn sort, ready nodes are taken in declaration order
+	deps := make(map[*GraphNode]map[*GraphNode]bool)
+	users := make(map[*GraphNode][]*GraphNode)
+	for _, n := range g.nodes {
+		deps[n] = make(map[*GraphNode]bool)
+		for _, in := range n.inputs {
+			if p := in.resource.producer; p != nil && p != n && !deps[n][p] {
+				deps[n][p] = true
+				users[p] = append(users[p], n)
+			}
+		}
+	}
+	order := make([]*GraphNode, 0, len(g.nodes))
+	done := make(map[*GraphNode]bool)
+	for len(order) < len(g.nodes) {
+		progress := false
+		for _, n := range g.nodes {
+			if done[n] {
+				continue
+			}
+			ready := true
+			for p := range deps[n] {
+				ready = ready && done[p]
+			}
+			if ready {
+				n.index = len(order)
+				order = append(order, n)
+				done[n] = true
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			cycle := make([]string, 0)
+			for _, n := range g.nodes {
+				if !done[n] {
+					cycle = append(cycle, n.name)
+				}
+			}
+			return errors.New("Graph: dependency cycle between " + strings.Join(cycle, ", "))
+		}
+	}
+	//Lifetimes in scheduled order
+	for i, n := range order {
+		for _, list := range [][]graphBinding{n.inputs, n.outputs} {
+			for _, b := range list {
+				r := b.resource
+				if r.first < 0 {
+					r.first = i
+				}
+				r.last = i
+			}
+		}
+	}
+	for _, r := range g.resources {
+		if !r.external && r.producer == nil && r.first >= 0 {
+			return errors.New("Graph: transient " + r.name + " is read but never written")
+		}
+		if r.keep || (r.external && r.first >= 0) {
+			r.last = len(order)
+		}
+	}
+	g.allocate(order)
+	g.planBarriers(order)
+	g.compiled = true
+	return nil
+}
+
+// allocate Assigning storage to transient resources, storage is shared when lifetimes don't overlap
+func (g *Graph) allocate(order []*GraphNode) {
+	free := g.physical
+	g.physical = nil
+	for _, p := range free {
+		p.lastUse = -1
+	}
+	for i := range order {
+		for _, r := range g.resources {
+			if r.external || r.first != i {
+				continue
+			}
+			var chosen *graphPhysical
+			for _, p := range g.physical {
+				if p.key == r.key() && p.lastUse < r.first && !r.keep {
+					chosen = p
+					break
+				}
+			}
+			if chosen == nil {
+				//Reuse storage from previous compilation before creating new one
+				for j, p := range free {
+					if p.key == r.key() {
+						chosen = p
+						free = append(free[:j], free[j+1:]...)
+						break
+					}
+				}
+				if chosen == nil {
+					chosen = g.create(r)
+				}
+				g.physical = append(g.physical, chosen)
+			}
+			chosen.lastUse = r.last
+			if r.keep {
+				//Kept storage is never handed to later resources
+				chosen.lastUse = len(order) + 1
+			}
+			r.physical = chosen
+		}
+	}
+	for _, p := range free {
+		p.close()
+	}
+}
+
+func (g *Graph) create(r *GraphResource) *graphPhysical {
+	p := &graphPhysical{key: r.key(), lastUse: -1}
+	if r.texture {
+		p.texture = g.c.NewTexture(r.texType, r.channels)
+		switch {
+		case r.z > 1:
+			p.texture.Create3D(r.x, r.y, r.z)
+		case r.y > 1:
+			p.texture.Create2D(r.x, r.y)
+		default:
+			p.texture.Create1D(r.x)
+		}
+	} else {
+		p.buffer = g.c.NewBufferV(BDynamicCopy, BStorage)
+		BufferAllocateBytes(p.buffer, r.bytes, 1)
+	}
+	return p
+}
+
+func (p *graphPhysical) close() {
+	if p.buffer != nil {
+		p.buffer.Close()
+	}
+	if p.texture != nil {
+		p.texture.Close()
+	}
+}
+
+// object Storage identity of resource, external and transient resources never alias each other
+func (r *GraphResource) object() interface{} {
+	if r.physical != nil {
+		return r.physical
+	}
+	return r
+}
+
+// planBarriers Placing barrier before node only when it touches storage written or read since previous barrier
+func (g *Graph) planBarriers(order []*GraphNode) {
+	written := make(map[interface{}]uint32)
+	read := make(map[interface{}]uint32)
+	g.plan = make([]graphStep, len(order))
+	for i, n := range order {
+		bits := uint32(0)
+		for _, in := range n.inputs {
+			bits |= written[in.resource.object()]
+		}
+		for _, out := range n.outputs {
+			bits |= written[out.resource.object()] | read[out.resource.object()]
+		}
+		if bits != 0 {
+			//Barrier makes every earlier access of these kinds visible
+			for key, bit := range written {
+				if bit&bits != 0 {
+					delete(written, key)
+				}
+			}
+			for key, bit := range read {
+				if bit&bits != 0 {
+					delete(read, key)
+				}
+			}
+		}
+		g.plan[i] = graphStep{n, bits}
+		for _, in := range n.inputs {
+			read[in.resource.object()] |= in.resource.barrierBit()
+		}
+		for _, out := range n.outputs {
+			written[out.resource.object()] |= out.resource.barrierBit()
+		}
+	}
+	g.finalBarrier = 0
+	for _, bit := range written {
+		if bit == gl.SHADER_IMAGE_ACCESS_BARRIER_BIT {
+			g.finalBarrier |= gl.TEXTURE_UPDATE_BARRIER_BIT | gl.PIXEL_BUFFER_BARRIER_BIT
+		} else {
+			g.finalBarrier |= gl.BUFFER_UPDATE_BARRIER_BIT
+		}
+	}
+}
+
+// Barriers Planned barrier bits before each node in execution order, for inspection
+func (g *Graph) Barriers() (names []string, barriers []uint32, err error) {
+	if !g.compiled {
+		if err = g.Compile(); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, step := range g.plan {
+		names = append(names, step.node.name)
+		barriers = append(barriers, step.barrier)
+	}
+	return names, barriers, nil
+}
+
+// Execute Running every node in planned order, compiling graph when it changed
+func (g *Graph) Execute() (err error) {
+	g.c.run(func() {
+		err = g.execute()
+	})
+	return err
+}
+
+func (g *Graph) execute() error {
+	if !g.compiled {
+		if err := g.compile(); err != nil {
+			return err
+		}
+	}
+	for _, r := range g.resources {
+		if r.external && r.first >= 0 && r.buffer == nil && r.tex == nil {
+			return errors.New("Graph: external " + r.name + " is not bound")
+		}
+	}
+	for _, step := range g.plan {
+		n := step.node
+		if step.barrier != 0 {
+			gl.MemoryBarrier(step.barrier)
+		}
+		g.c.UseProgram(n.program)
+		for _, list := range [][]graphBinding{n.inputs, n.outputs} {
+			for _, b := range list {
+				if b.resource.texture {
+					if err := b.resource.Texture().SetBinding(b.binding); err != nil {
+						return err
+					}
+				} else {
+					b.resource.Buffer().SetBinding(b.binding)
+				}
+			}
+		}
+		for _, set := range n.uniforms {
+			set(g.c)
+		}
+		x, y, z := n.size()
+		if n.threads {
+			group := g.c.computeGroups[n.program]
+			if group != nil {
+				x, y, z = (x+group.X-1)/group.X, (y+group.Y-1)/group.Y, (z+group.Z-1)/group.Z
+			}
+		}
+		g.c.Realize(x, y, z)
+	}
+	if g.finalBarrier != 0 {
+		gl.MemoryBarrier(g.finalBarrier)
+	}
+	CheckErr("Graph.Execute")
+	return nil
+}
+
+// Close Deleting transient storage, external resources stay with caller
+func (g *Graph) Close() {
+	g.c.run(func() {
+		for _, p := range g.physical {
+			p.close()
+		}
+		g.physical = nil
+		for _, r := range g.resources {
+			r.physical = nil
+		}
+		g.compiled = false
+	})
+}
@@ -0,0 +1,149 @@
+package gocompute
+
+import (
+	"math"
+	"reflect"
+)
+
+// Half IEEE 754 binary16 value, layout matches GL HALF_FLOAT and GLSL packHalf2x16 halves
+type Half uint16
+
+const (
+	HalfInf    Half = 0x7C00
+	HalfNegInf Half = 0xFC00
+	HalfNaN    Half = 0x7E00
+	// HalfMax largest finite half value 65504
+	HalfMax Half = 0x7BFF
+)
+
+// HalfFromFloat32 Converting with round to nearest even, out of range values become infinity
+func HalfFromFloat32(f float32) Half {
+	bits := math.Float32bits(f)
+	sign := uint32(bits>>16) & 0x8000
+	exp := int32(bits>>23) & 0xFF
+	mant := bits & 0x7FFFFF
+	if exp == 0xFF {
+		if mant != 0 {
+			//Keep NaN quiet and top bits of payload
+			return Half(sign | 0x7E00 | mant>>13)
+		}
+		return Half(sign | 0x7C00)
+	}
+	e := exp - 127 + 15
+	if e >= 0x1F {
+		return Half(sign | 0x7C00)
+	}
+	if e <= 0 {
+		//Subnormal half or zero
+		if e < -10 {
+			return Half(sign)
+		}
+		mant |= 0x800000
+		shift := uint32(14 - e)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return Half(sign | half)
+	}
+	half := uint32(e)<<10 | mant>>13
+	rem := mant & 0x1FFF
+	//Carry out of mantissa increments exponent, rounding up to infinity when needed
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++
+	}
+	return Half(sign | half)
+}
+
+// Float32 Exact conversion to float32
+func (h Half) Float32() float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1F
+	mant := uint32(h & 0x3FF)
+	switch exp {
+	case 0x1F:
+		return math.Float32frombits(sign | 0x7F800000 | mant<<13)
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		//Normalize subnormal half
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		mant &= 0x3FF
+		return math.Float32frombits(sign | e<<23 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
+
+func (h Half) IsNaN() bool {
+	return h&0x7C00 == 0x7C00 && h&0x3FF != 0
+}
+
+func (h Half) IsInf() bool {
+	return h&0x7FFF == 0x7C00
+}
+
+// HalfsFromFloat32 Converting src into dst, returns count of converted values
+func HalfsFromFloat32(dst []Half, src []float32) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = HalfFromFloat32(src[i])
+	}
+	return n
+}
+
+// Float32sFromHalf Converting src into dst, returns count of converted values
+func Float32sFromHalf(dst []float32, src []Half) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = src[i].Float32()
+	}
+	return n
+}
+
+// ToHalf Converting slice into new half slice
+func ToHalf(src []float32) []Half {
+	dst := make([]Half, len(src))
+	HalfsFromFloat32(dst, src)
+	return dst
+}
+
+// FromHalf Converting half slice into new float32 slice
+func FromHalf(src []Half) []float32 {
+	dst := make([]float32, len(src))
+	Float32sFromHalf(dst, src)
+	return dst
+}
+
+// PackHalf2x16 Same packing as GLSL packHalf2x16, x in low 16 bits
+func PackHalf2x16(x, y float32) uint32 {
+	return uint32(HalfFromFloat32(x)) | uint32(HalfFromFloat32(y))<<16
+}
+
+// UnpackHalf2x16 Same unpacking as GLSL unpackHalf2x16
+func UnpackHalf2x16(v uint32) (float32, float32) {
+	return Half(v).Float32(), Half(v >> 16).Float32()
+}
+
+var halfType = reflect.TypeOf(Half(0))
+
+// isHalf Element type is Half or array of Half
+func isHalf[V any]() bool {
+	t := reflect.TypeOf((*V)(nil)).Elem()
+	for t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t == halfType
+}
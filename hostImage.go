@@ -0,0 +1,191 @@
+package gocompute
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"unsafe"
+)
+
+// HostImage Host side copy of texture or buffer contents
+// Data is interleaved in client layout of Type, native byte order
+type HostImage struct {
+	Width, Height, Depth int
+	Channels             int
+	Type                 TextureType
+	Data                 []byte
+}
+
+// imageBytes Size of image data, negative dimensions and sizes overflowing int are rejected
+func imageBytes(width, height, depth, texelSize int) (int, error) {
+	size := texelSize
+	for _, d := range []int{width, height, depth} {
+		if d < 0 {
+			return 0, errors.New("wrong image size " + strconv.Itoa(width) + "x" + strconv.Itoa(height) + "x" + strconv.Itoa(depth))
+		}
+		if d != 0 && size > math.MaxInt/d {
+			return 0, errors.New("image size " + strconv.Itoa(width) + "x" + strconv.Itoa(height) + "x" + strconv.Itoa(depth) + " is too large")
+		}
+		size *= d
+	}
+	return size, nil
+}
+
+// NewHostImage Allocating zeroed image for type and channels combination
+func NewHostImage(width, height, depth, channels int, texType TextureType) (*HostImage, error) {
+	img, size, err := newHostImage(width, height, depth, channels, texType)
+	if err != nil {
+		return nil, err
+	}
+	img.Data = make([]byte, size)
+	return img, nil
+}
+
+// newHostImage Image without data and size of data it requires, dimensions must be positive
+func newHostImage(width, height, depth, channels int, texType TextureType) (*HostImage, int, error) {
+	format, err := TextureFormatOf(texType, channels)
+	if err != nil {
+		return nil, 0, err
+	}
+	if width <= 0 || height <= 0 || depth <= 0 {
+		return nil, 0, errors.New("wrong image size " + strconv.Itoa(width) + "x" + strconv.Itoa(height) + "x" + strconv.Itoa(depth))
+	}
+	size, err := imageBytes(width, height, depth, format.TexelSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &HostImage{width, height, depth, channels, texType, nil}, size, nil
+}
+
+// readHostImage Image with data read from r, data is read as it arrives instead of allocated from untrusted header
+func readHostImage(r io.Reader, width, height, depth, channels int, texType TextureType) (*HostImage, error) {
+	img, size, err := newHostImage(width, height, depth, channels, texType)
+	if err != nil {
+		return nil, err
+	}
+	img.Data, err = io.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if len(img.Data) != size {
+		return nil, errors.New("image data has " + strconv.Itoa(len(img.Data)) + " bytes, size requires " + strconv.Itoa(size))
+	}
+	return img, nil
+}
+
+// TexelSize Bytes per texel of Data
+func (img *HostImage) TexelSize() int {
+	format, _ := TextureFormatOf(img.Type, img.Channels)
+	return format.TexelSize
+}
+
+// SampleSize Bytes per channel of Data, zero for packed types
+func (img *HostImage) SampleSize() int {
+	if _, packed := packedFormats[img.Type]; packed {
+		return 0
+	}
+	return img.TexelSize() / img.Channels
+}
+
+func (img *HostImage) validate() error {
+	format, err := TextureFormatOf(img.Type, img.Channels)
+	if err != nil {
+		return err
+	}
+	need, err := imageBytes(img.Width, img.Height, img.Depth, format.TexelSize)
+	if err != nil {
+		return err
+	}
+	if len(img.Data) != need {
+		return errors.New("host image holds " + strconv.Itoa(len(img.Data)) + " bytes, dimensions require " + strconv.Itoa(need))
+	}
+	return nil
+}
+
+// TextureToHost Reading current level of texture into new host image
+func TextureToHost(t *GpuTexture) (*HostImage, error) {
+	img, err := NewHostImage(t.SizeX, t.SizeY, t.SizeZ, t.channels, t.texType)
+	if err != nil {
+		return nil, err
+	}
+	if err = TextureReadInto(t, img.Data); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// TextureFromHost Creating texture matching host image, 1D for single row, 3D for Depth > 1
+func (c *Computing) TextureFromHost(img *HostImage) (*GpuTexture, error) {
+	if err := img.validate(); err != nil {
+		return nil, err
+	}
+	t := c.NewTexture(img.Type, img.Channels)
+	var err error
+	switch {
+	case img.Depth > 1:
+		err = t.Create3D(img.Width, img.Height, img.Depth)
+	case img.Height > 1:
+		err = t.Create2D(img.Width, img.Height)
+	default:
+		err = t.Create1D(img.Width)
+	}
+	if err != nil {
+		t.Close()
+		return nil, err
+	}
+	switch {
+	case img.Depth > 1:
+		TextureLoad3D(t, img.Data)
+	case img.Height > 1:
+		TextureLoad2D(t, img.Data)
+	default:
+		TextureLoad1D(t, img.Data)
+	}
+	return t, nil
+}
+
+// BufferToHost Reading buffer as image with given dimensions and element layout
+func BufferToHost(b *GpuBuffer, width, height, depth, channels int, texType TextureType) (*HostImage, error) {
+	img, err := NewHostImage(width, height, depth, channels, texType)
+	if err != nil {
+		return nil, err
+	}
+	if size := b.ByteSize(); size < len(img.Data) {
+		return nil, errors.New("BufferToHost: buffer holds " + strconv.Itoa(size) + " bytes, image requires " + strconv.Itoa(len(img.Data)))
+	}
+	BufferReadInto(b, img.Data, 0)
+	return img, nil
+}
+
+// BufferFromHost Loading host image data into buffer
+func BufferFromHost(b *GpuBuffer, img *HostImage) error {
+	if err := img.validate(); err != nil {
+		return err
+	}
+	if len(img.Data) == 0 {
+		return errors.New("BufferFromHost: empty image")
+	}
+	BufferLoad(b, img.Data)
+	return nil
+}
+
+// HostImageOf Wrapping typed slice as host image without copying
+func HostImageOf[V any](data []V, width, height, depth, channels int, texType TextureType) (*HostImage, error) {
+	img := &HostImage{width, height, depth, channels, texType, nil}
+	if len(data) > 0 {
+		img.Data = toSlice[byte](unsafe.Pointer(&data[0]), len(data)*tSize[V]())
+	}
+	if err := img.validate(); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// HostImageData Viewing image data as typed slice without copying
+func HostImageData[V any](img *HostImage) []V {
+	if len(img.Data) == 0 {
+		return nil
+	}
+	return toSlice[V](unsafe.Pointer(&img.Data[0]), len(img.Data)/tSize[V]())
+}
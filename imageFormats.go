@@ -0,0 +1,482 @@
+package gocompute
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RawLayout Channel order of raw dumps
+type RawLayout string
+
+const (
+	RawInterleaved RawLayout = "interleaved"
+	RawPlanar      RawLayout = "planar"
+)
+
+// RawInfo JSON sidecar describing raw dump
+type RawInfo struct {
+	Width    int       `json:"width"`
+	Height   int       `json:"height"`
+	Depth    int       `json:"depth"`
+	Channels int       `json:"channels"`
+	Type     string    `json:"type"`
+	Layout   RawLayout `json:"layout"`
+}
+
+func floatData(img *HostImage) bool {
+	return img.Type == FLOAT32 || img.Type == FLOAT16
+}
+
+// WritePFM Writing float image with 1 or 3 channels as little endian PFM
+func WritePFM(w io.Writer, img *HostImage) error {
+	if err := img.validate(); err != nil {
+		return err
+	}
+	if img.Type != FLOAT32 || img.Depth != 1 || (img.Channels != 1 && img.Channels != 3) {
+		return errors.New("WritePFM: requires 2D FLOAT32 image with 1 or 3 channels")
+	}
+	magic := "Pf"
+	if img.Channels == 3 {
+		magic = "PF"
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString(magic + "\n" + strconv.Itoa(img.Width) + " " + strconv.Itoa(img.Height) + "\n-1.0\n")
+	data := HostImageData[float32](img)
+	row := img.Width * img.Channels
+	buf := make([]byte, 4)
+	//PFM rows are stored bottom to top
+	for y := img.Height - 1; y >= 0; y-- {
+		for _, v := range data[y*row : (y+1)*row] {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(v))
+			bw.Write(buf)
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadPFM Reading PFM into FLOAT32 image
+func ReadPFM(r io.Reader) (*HostImage, error) {
+	br := bufio.NewReader(r)
+	tokens, err := readHeader(br, 4)
+	if err != nil {
+		return nil, err
+	}
+	channels := 0
+	switch tokens[0] {
+	case "Pf":
+		channels = 1
+	case "PF":
+		channels = 3
+	default:
+		return nil, errors.New("ReadPFM: wrong magic " + tokens[0])
+	}
+	width, height, err := parseSize(tokens[1], tokens[2])
+	if err != nil {
+		return nil, err
+	}
+	scale, err := strconv.ParseFloat(tokens[3], 64)
+	if err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if scale < 0 {
+		order = binary.LittleEndian
+	}
+	//Header size is untrusted, data is read before image is allocated
+	source, err := readHostImage(br, width, height, 1, channels, FLOAT32)
+	if err != nil {
+		return nil, err
+	}
+	raw := source.Data
+	img, err := NewHostImage(width, height, 1, channels, FLOAT32)
+	if err != nil {
+		return nil, err
+	}
+	data := HostImageData[float32](img)
+	row := width * channels
+	for y := 0; y < height; y++ {
+		src := raw[(height-1-y)*row*4:]
+		for i := 0; i < row; i++ {
+			data[y*row+i] = math.Float32frombits(order.Uint32(src[i*4:]))
+		}
+	}
+	return img, nil
+}
+
+// WritePNM Writing 8 or 16 bit image as binary PGM (1 channel) or PPM (3 channels)
+func WritePNM(w io.Writer, img *HostImage) error {
+	if err := img.validate(); err != nil {
+		return err
+	}
+	sample := img.SampleSize()
+	if floatData(img) || (sample != 1 && sample != 2) || img.Depth != 1 || (img.Channels != 1 && img.Channels != 3) {
+		return errors.New("WritePNM: requires 2D 8 or 16 bit integer image with 1 or 3 channels")
+	}
+	magic := "P5"
+	if img.Channels == 3 {
+		magic = "P6"
+	}
+	maxVal := "255"
+	if sample == 2 {
+		maxVal = "65535"
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString(magic + "\n" + strconv.Itoa(img.Width) + " " + strconv.Itoa(img.Height) + "\n" + maxVal + "\n")
+	if sample == 1 {
+		bw.Write(img.Data)
+	} else {
+		//16 bit PNM samples are big endian
+		buf := make([]byte, 2)
+		for _, v := range HostImageData[uint16](img) {
+			binary.BigEndian.PutUint16(buf, v)
+			bw.Write(buf)
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadPNM Reading binary PGM or PPM into SIMPLE8 or SIMPLE16 image, samples are not rescaled to maxval
+func ReadPNM(r io.Reader) (*HostImage, error) {
+	br := bufio.NewReader(r)
+	tokens, err := readHeader(br, 4)
+	if err != nil {
+		return nil, err
+	}
+	channels := 0
+	switch tokens[0] {
+	case "P5":
+		channels = 1
+	case "P6":
+		channels = 3
+	default:
+		return nil, errors.New("ReadPNM: unsupported magic " + tokens[0])
+	}
+	width, height, err := parseSize(tokens[1], tokens[2])
+	if err != nil {
+		return nil, err
+	}
+	maxVal, err := strconv.Atoi(tokens[3])
+	if err != nil || maxVal <= 0 || maxVal > 65535 {
+		return nil, errors.New("ReadPNM: wrong maxval " + tokens[3])
+	}
+	texType := SIMPLE8
+	if maxVal > 255 {
+		texType = SIMPLE16
+	}
+	img, err := readHostImage(br, width, height, 1, channels, texType)
+	if err != nil {
+		return nil, err
+	}
+	if texType == SIMPLE16 {
+		data := HostImageData[uint16](img)
+		for i := range data {
+			data[i] = binary.BigEndian.Uint16(img.Data[i*2:])
+		}
+	}
+	return img, nil
+}
+
+// WritePNG Writing 8 or 16 bit image with 1, 3 or 4 channels as PNG
+func WritePNG(w io.Writer, img *HostImage) error {
+	if err := img.validate(); err != nil {
+		return err
+	}
+	sample := img.SampleSize()
+	if floatData(img) || (sample != 1 && sample != 2) || img.Depth != 1 || img.Channels == 2 {
+		return errors.New("WritePNG: requires 2D 8 or 16 bit integer image with 1, 3 or 4 channels")
+	}
+	rect := image.Rect(0, 0, img.Width, img.Height)
+	var out image.Image
+	switch {
+	case img.Channels == 1 && sample == 1:
+		out = &image.Gray{Pix: img.Data, Stride: img.Width, Rect: rect}
+	case img.Channels == 1:
+		gray := image.NewGray16(rect)
+		for i, v := range HostImageData[uint16](img) {
+			binary.BigEndian.PutUint16(gray.Pix[i*2:], v)
+		}
+		out = gray
+	case sample == 1:
+		rgba := image.NewNRGBA(rect)
+		for i := 0; i < img.Width*img.Height; i++ {
+			copy(rgba.Pix[i*4:i*4+img.Channels], img.Data[i*img.Channels:(i+1)*img.Channels])
+			if img.Channels == 3 {
+				rgba.Pix[i*4+3] = 0xFF
+			}
+		}
+		out = rgba
+	default:
+		rgba := image.NewNRGBA64(rect)
+		data := HostImageData[uint16](img)
+		for i := 0; i < img.Width*img.Height; i++ {
+			for c := 0; c < 4; c++ {
+				v := uint16(0xFFFF)
+				if c < img.Channels {
+					v = data[i*img.Channels+c]
+				}
+				binary.BigEndian.PutUint16(rgba.Pix[i*8+c*2:], v)
+			}
+		}
+		out = rgba
+	}
+	return png.Encode(w, out)
+}
+
+// ReadPNG Reading PNG into SIMPLE8 or SIMPLE16 image
+// Grayscale gives 1 channel, opaque color 3 channels and everything else 4 channels
+func ReadPNG(r io.Reader) (*HostImage, error) {
+	src, err := png.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	//Source pixel layout: bytes per pixel, channels taken from each pixel
+	var pix []byte
+	stride, pixSize, channels, texType := 0, 4, 4, SIMPLE8
+	switch src := src.(type) {
+	case *image.Gray:
+		pix, stride, pixSize, channels = src.Pix, src.Stride, 1, 1
+	case *image.Gray16:
+		pix, stride, pixSize, channels, texType = src.Pix, src.Stride, 2, 1, SIMPLE16
+	case *image.RGBA:
+		pix, stride, pixSize, channels = src.Pix, src.Stride, 4, 3
+	case *image.RGBA64:
+		pix, stride, pixSize, channels, texType = src.Pix, src.Stride, 8, 3, SIMPLE16
+	case *image.NRGBA:
+		pix, stride = src.Pix, src.Stride
+	case *image.NRGBA64:
+		pix, stride, pixSize, texType = src.Pix, src.Stride, 8, SIMPLE16
+	default:
+		converted := image.NewNRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				converted.Set(x, y, src.At(bounds.Min.X+x, bounds.Min.Y+y))
+			}
+		}
+		pix, stride = converted.Pix, converted.Stride
+	}
+	img, err := NewHostImage(width, height, 1, channels, texType)
+	if err != nil {
+		return nil, err
+	}
+	wide := HostImageData[uint16](img)
+	i := 0
+	for y := 0; y < height; y++ {
+		row := pix[y*stride:]
+		for x := 0; x < width; x++ {
+			for c := 0; c < channels; c++ {
+				if texType == SIMPLE16 {
+					wide[i] = binary.BigEndian.Uint16(row[x*pixSize+c*2:])
+				} else {
+					img.Data[i] = row[x*pixSize+c]
+				}
+				i++
+			}
+		}
+	}
+	return img, nil
+}
+
+// WriteRaw Writing image data to path with JSON sidecar at path + ".json"
+func WriteRaw(path string, img *HostImage, layout RawLayout) error {
+	if err := img.validate(); err != nil {
+		return err
+	}
+	data := img.Data
+	switch layout {
+	case RawInterleaved:
+	case RawPlanar:
+		sample := img.SampleSize()
+		if sample == 0 {
+			return errors.New("WriteRaw: packed type " + img.Type.String() + " can't be stored planar")
+		}
+		data = make([]byte, len(img.Data))
+		reorder(data, img.Data, img.Width*img.Height*img.Depth, img.Channels, sample, false)
+	default:
+		return errors.New("WriteRaw: unknown layout " + string(layout))
+	}
+	info, err := json.MarshalIndent(RawInfo{img.Width, img.Height, img.Depth, img.Channels, img.Type.String(), layout}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(path+".json", info, 0644)
+}
+
+// ReadRaw Reading raw dump described by JSON sidecar at path + ".json", result is always interleaved
+func ReadRaw(path string) (*HostImage, error) {
+	infoData, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return nil, err
+	}
+	info := RawInfo{}
+	if err = json.Unmarshal(infoData, &info); err != nil {
+		return nil, err
+	}
+	texType, err := ParseTextureType(info.Type)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img := &HostImage{info.Width, info.Height, info.Depth, info.Channels, texType, data}
+	if err = img.validate(); err != nil {
+		return nil, err
+	}
+	switch info.Layout {
+	case RawInterleaved, "":
+	case RawPlanar:
+		sample := img.SampleSize()
+		if sample == 0 {
+			return nil, errors.New("ReadRaw: packed type " + info.Type + " can't be stored planar")
+		}
+		img.Data = make([]byte, len(data))
+		reorder(img.Data, data, img.Width*img.Height*img.Depth, img.Channels, sample, true)
+	default:
+		return nil, errors.New("ReadRaw: unknown layout " + string(info.Layout))
+	}
+	return img, nil
+}
+
+// SaveImage Writing image by file extension: .pfm, .pgm, .ppm, .png or .raw (interleaved)
+func SaveImage(path string, img *HostImage) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".raw" {
+		return WriteRaw(path, img, RawInterleaved)
+	}
+	var write func(io.Writer, *HostImage) error
+	switch ext {
+	case ".pfm":
+		write = WritePFM
+	case ".pgm", ".ppm", ".pnm":
+		write = WritePNM
+	case ".png":
+		write = WritePNG
+	default:
+		return errors.New("SaveImage: unsupported extension " + ext)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = write(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadImage Reading image by file extension: .pfm, .pgm, .ppm, .png or .raw
+func LoadImage(path string) (*HostImage, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".raw" {
+		return ReadRaw(path)
+	}
+	var read func(io.Reader) (*HostImage, error)
+	switch ext {
+	case ".pfm":
+		read = ReadPFM
+	case ".pgm", ".ppm", ".pnm":
+		read = ReadPNM
+	case ".png":
+		read = ReadPNG
+	default:
+		return nil, errors.New("LoadImage: unsupported extension " + ext)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return read(file)
+}
+
+// SaveTexture Reading texture and writing it by file extension
+func SaveTexture(t *GpuTexture, path string) error {
+	img, err := TextureToHost(t)
+	if err != nil {
+		return err
+	}
+	return SaveImage(path, img)
+}
+
+// LoadTexture Reading image file into new texture
+func (c *Computing) LoadTexture(path string) (*GpuTexture, error) {
+	img, err := LoadImage(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.TextureFromHost(img)
+}
+
+// reorder Converting between interleaved and planar sample order
+func reorder(dst, src []byte, texels, channels, sample int, toInterleaved bool) {
+	for p := 0; p < texels; p++ {
+		for c := 0; c < channels; c++ {
+			inter := (p*channels + c) * sample
+			planar := (c*texels + p) * sample
+			if toInterleaved {
+				copy(dst[inter:inter+sample], src[planar:planar+sample])
+			} else {
+				copy(dst[planar:planar+sample], src[inter:inter+sample])
+			}
+		}
+	}
+}
+
+// readHeader Reading whitespace separated netpbm header tokens, skipping comments
+// Consumes exactly one whitespace byte after last token
+func readHeader(br *bufio.Reader, count int) ([]string, error) {
+	tokens := make([]string, 0, count)
+	token := ""
+	for len(tokens) < count {
+		ch, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case ch == '#' && token == "":
+			if _, err = br.ReadString('\n'); err != nil {
+				return nil, err
+			}
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if token != "" {
+				tokens = append(tokens, token)
+				token = ""
+			}
+		default:
+			token += string(ch)
+		}
+	}
+	return tokens, nil
+}
+
+func parseSize(w, h string) (int, int, error) {
+	width, err := strconv.Atoi(w)
+	if err != nil {
+		return 0, 0, err
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil {
+		return 0, 0, err
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, errors.New("wrong image size " + w + "x" + h)
+	}
+	return width, height, nil
+}
@@ -0,0 +1,84 @@
+package imgproc
+
+import (
+	gc "github.com/eszdman/gocompute"
+)
+
+// ColorConversion Color space conversion of first three channels, alpha is kept
+type ColorConversion int
+
+const (
+	// RGBToGray BT.601 luma, dst nil allocates one channel texture
+	RGBToGray ColorConversion = iota
+	// RGBToYCbCr Full range BT.601 with chroma centered at 0.5
+	RGBToYCbCr
+	YCbCrToRGB
+	// RGBToHSV Hue, saturation and value in 0..1
+	RGBToHSV
+	HSVToRGB
+	SRGBToLinear
+	LinearToSRGB
+)
+
+const colorKernel = `
+uniform int conversion;
+
+vec3 rgbToHsv(vec3 c) {
+	vec4 k = vec4(0.0, -1.0 / 3.0, 2.0 / 3.0, -1.0);
+	vec4 p = mix(vec4(c.bg, k.wz), vec4(c.gb, k.xy), step(c.b, c.g));
+	vec4 q = mix(vec4(p.xyw, c.r), vec4(c.r, p.yzx), step(p.x, c.r));
+	float d = q.x - min(q.w, q.y);
+	float e = 1.0e-10;
+	return vec3(abs(q.z + (q.w - q.y) / (6.0 * d + e)), d / (q.x + e), q.x);
+}
+
+vec3 hsvToRgb(vec3 c) {
+	vec4 k = vec4(1.0, 2.0 / 3.0, 1.0 / 3.0, 3.0);
+	vec3 p = abs(fract(c.xxx + k.xyz) * 6.0 - k.www);
+	return c.z * mix(k.xxx, clamp(p - k.xxx, 0.0, 1.0), c.y);
+}
+
+vec3 srgbToLinear(vec3 c) {
+	return mix(c / 12.92, pow((c + 0.055) / 1.055, vec3(2.4)), greaterThan(c, vec3(0.04045)));
+}
+
+vec3 linearToSrgb(vec3 c) {
+	return mix(c * 12.92, 1.055 * pow(c, vec3(1.0 / 2.4)) - 0.055, greaterThan(c, vec3(0.0031308)));
+}
+` + pixelStart + `
+	vec4 value = fetch(pos);
+	vec3 c = value.rgb;
+	if (conversion == 0) {
+		c = vec3(dot(c, vec3(0.299, 0.587, 0.114)));
+	} else if (conversion == 1) {
+		c = vec3(dot(c, vec3(0.299, 0.587, 0.114)),
+			0.5 + dot(c, vec3(-0.168736, -0.331264, 0.5)),
+			0.5 + dot(c, vec3(0.5, -0.418688, -0.081312)));
+	} else if (conversion == 2) {
+		vec3 d = c - vec3(0.0, 0.5, 0.5);
+		c = vec3(d.x + 1.402 * d.z, d.x - 0.344136 * d.y - 0.714136 * d.z, d.x + 1.772 * d.y);
+	} else if (conversion == 3) {
+		c = rgbToHsv(c);
+	} else if (conversion == 4) {
+		c = hsvToRgb(c);
+	} else if (conversion == 5) {
+		c = srgbToLinear(c);
+	} else {
+		c = linearToSrgb(c);
+	}
+	imageStore(dst, pos, vec4(c, value.a));
+}
+`
+
+// ConvertColor Converting color space, dst nil allocates texture like src
+func (p *Imgproc) ConvertColor(src, dst *gc.GpuTexture, conversion ColorConversion) (*gc.GpuTexture, error) {
+	channels := src.Channels()
+	if conversion == RGBToGray {
+		channels = 1
+	}
+	return p.apply(src, dst, src.Type(), channels, src.SizeX, src.SizeY, func(dst *gc.GpuTexture) error {
+		return p.run(colorKernel, src, dst, BorderClamp, func() {
+			p.c.SetInt("conversion", int(conversion))
+		})
+	})
+}
@@ -0,0 +1,225 @@
+package imgproc
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+	"math"
+	"strings"
+)
+
+// pixelStart Invocation pixel with bounds check
+const pixelStart = `
+void main() {
+	ivec2 pos = ivec2(gl_GlobalInvocationID.xy);
+	if (any(greaterThanEqual(pos, outSize))) {
+		return;
+	}
+`
+
+const separableKernel = `
+layout(std430, binding = 0) readonly buffer filterWeights {
+	float weights[];
+};
+uniform ivec2 direction;
+uniform int radius;
+` + pixelStart + `
+	vec4 sum = vec4(0.0);
+	for (int i = -radius; i <= radius; i++) {
+		sum += weights[i + radius] * fetch(pos + direction * i);
+	}
+	imageStore(dst, pos, sum);
+}
+`
+
+const convolveKernel = `
+layout(std430, binding = 0) readonly buffer filterWeights {
+	float weights[];
+};
+uniform ivec2 kernelSize;
+` + pixelStart + `
+	ivec2 anchor = kernelSize / 2;
+	vec4 sum = vec4(0.0);
+	for (int y = 0; y < kernelSize.y; y++) {
+		for (int x = 0; x < kernelSize.x; x++) {
+			sum += weights[y * kernelSize.x + x] * fetch(pos + ivec2(x, y) - anchor);
+		}
+	}
+	imageStore(dst, pos, sum);
+}
+`
+
+const bilateralKernel = `
+uniform int radius;
+uniform float sigmaSpace;
+uniform float sigmaColor;
+` + pixelStart + `
+	vec4 center = fetch(pos);
+	vec4 sum = vec4(0.0);
+	float total = 0.0;
+	for (int y = -radius; y <= radius; y++) {
+		for (int x = -radius; x <= radius; x++) {
+			vec4 value = fetch(pos + ivec2(x, y));
+			vec4 difference = value - center;
+			float weight = exp(-0.5 * float(x * x + y * y) / (sigmaSpace * sigmaSpace) - 0.5 * dot(difference, difference) / (sigmaColor * sigmaColor));
+			sum += weight * value;
+			total += weight;
+		}
+	}
+	imageStore(dst, pos, sum / total);
+}
+`
+
+// medianKernel Per channel selection of middle value, MEDIAN_RADIUS is replaced by 1 or 2
+const medianKernel = `
+` + pixelStart + `
+	const int radius = MEDIAN_RADIUS;
+	const int count = (2 * radius + 1) * (2 * radius + 1);
+	vec4 values[count];
+	int n = 0;
+	for (int y = -radius; y <= radius; y++) {
+		for (int x = -radius; x <= radius; x++) {
+			values[n++] = fetch(pos + ivec2(x, y));
+		}
+	}
+	for (int i = 0; i <= count / 2; i++) {
+		for (int j = i + 1; j < count; j++) {
+			vec4 low = min(values[i], values[j]);
+			values[j] = max(values[i], values[j]);
+			values[i] = low;
+		}
+	}
+	imageStore(dst, pos, values[count / 2]);
+}
+`
+
+const sobelKernel = `
+float luma(vec4 v) {
+	return channels >= 3 ? dot(v.rgb, vec3(0.299, 0.587, 0.114)) : v.r;
+}
+` + pixelStart + `
+	float p00 = luma(fetch(pos + ivec2(-1, -1)));
+	float p10 = luma(fetch(pos + ivec2(0, -1)));
+	float p20 = luma(fetch(pos + ivec2(1, -1)));
+	float p01 = luma(fetch(pos + ivec2(-1, 0)));
+	float p21 = luma(fetch(pos + ivec2(1, 0)));
+	float p02 = luma(fetch(pos + ivec2(-1, 1)));
+	float p12 = luma(fetch(pos + ivec2(0, 1)));
+	float p22 = luma(fetch(pos + ivec2(1, 1)));
+	float gx = (p20 + 2.0 * p21 + p22) - (p00 + 2.0 * p01 + p02);
+	float gy = (p02 + 2.0 * p12 + p22) - (p00 + 2.0 * p10 + p20);
+	imageStore(dst, pos, vec4(gx, gy, length(vec2(gx, gy)), atan(gy, gx)));
+}
+`
+
+// GaussianKernel Normalized 1D weights of radius ceil(3 sigma)
+func GaussianKernel(sigma float32) []float32 {
+	radius := int(math.Ceil(3 * float64(sigma)))
+	if radius < 1 {
+		radius = 1
+	}
+	weights := make([]float32, 2*radius+1)
+	total := float32(0)
+	for i := range weights {
+		d := float64(i-radius) / float64(sigma)
+		weights[i] = float32(math.Exp(-0.5 * d * d))
+		total += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= total
+	}
+	return weights
+}
+
+// Separable Filtering rows with kernelX and columns with kernelY, kernels have odd length
+// dst nil allocates texture like src
+func (p *Imgproc) Separable(src, dst *gc.GpuTexture, kernelX, kernelY []float32, border Border) (*gc.GpuTexture, error) {
+	if len(kernelX)%2 == 0 || len(kernelY)%2 == 0 {
+		return nil, errors.New("imgproc: separable kernels must have odd length")
+	}
+	return p.apply(src, dst, src.Type(), src.Channels(), src.SizeX, src.SizeY, func(dst *gc.GpuTexture) error {
+		temporary, err := p.temporary(src.Channels(), src.SizeX, src.SizeY)
+		if err != nil {
+			return err
+		}
+		pass := func(from, to *gc.GpuTexture, weights []float32, dx, dy int) error {
+			p.loadWeights(weights)
+			return p.run(separableKernel, from, to, border, func() {
+				p.c.SetInt("direction", dx, dy)
+				p.c.SetInt("radius", len(weights)/2)
+			})
+		}
+		if err = pass(src, temporary, kernelX, 1, 0); err != nil {
+			return err
+		}
+		return pass(temporary, dst, kernelY, 0, 1)
+	})
+}
+
+// GaussianBlur Separable gaussian blur
+func (p *Imgproc) GaussianBlur(src, dst *gc.GpuTexture, sigma float32, border Border) (*gc.GpuTexture, error) {
+	if sigma <= 0 {
+		return nil, errors.New("imgproc: gaussian sigma must be positive")
+	}
+	weights := GaussianKernel(sigma)
+	return p.Separable(src, dst, weights, weights, border)
+}
+
+// BoxBlur Mean of (2 radius + 1) squared neighbourhood
+func (p *Imgproc) BoxBlur(src, dst *gc.GpuTexture, radius int, border Border) (*gc.GpuTexture, error) {
+	if radius < 0 {
+		return nil, errors.New("imgproc: negative box radius")
+	}
+	weights := make([]float32, 2*radius+1)
+	for i := range weights {
+		weights[i] = 1 / float32(len(weights))
+	}
+	return p.Separable(src, dst, weights, weights, border)
+}
+
+// Convolve Correlation with width by height row major kernel anchored at its center
+func (p *Imgproc) Convolve(src, dst *gc.GpuTexture, kernel []float32, width, height int, border Border) (*gc.GpuTexture, error) {
+	if width <= 0 || height <= 0 || len(kernel) != width*height {
+		return nil, fmt.Errorf("imgproc: %dx%d kernel from %d weights", width, height, len(kernel))
+	}
+	return p.apply(src, dst, src.Type(), src.Channels(), src.SizeX, src.SizeY, func(dst *gc.GpuTexture) error {
+		p.loadWeights(kernel)
+		return p.run(convolveKernel, src, dst, border, func() {
+			p.c.SetInt("kernelSize", width, height)
+		})
+	})
+}
+
+// Bilateral Edge preserving blur weighted by distance and color difference
+func (p *Imgproc) Bilateral(src, dst *gc.GpuTexture, sigmaSpace, sigmaColor float32, border Border) (*gc.GpuTexture, error) {
+	if sigmaSpace <= 0 || sigmaColor <= 0 {
+		return nil, errors.New("imgproc: bilateral sigmas must be positive")
+	}
+	return p.apply(src, dst, src.Type(), src.Channels(), src.SizeX, src.SizeY, func(dst *gc.GpuTexture) error {
+		return p.run(bilateralKernel, src, dst, border, func() {
+			p.c.SetInt("radius", int(math.Ceil(2*float64(sigmaSpace))))
+			p.c.SetFloat32("sigmaSpace", sigmaSpace)
+			p.c.SetFloat32("sigmaColor", sigmaColor)
+		})
+	})
+}
+
+// Median Per channel median of size by size neighbourhood, size is 3 or 5
+func (p *Imgproc) Median(src, dst *gc.GpuTexture, size int, border Border) (*gc.GpuTexture, error) {
+	if size != 3 && size != 5 {
+		return nil, errors.New("imgproc: median size must be 3 or 5")
+	}
+	return p.apply(src, dst, src.Type(), src.Channels(), src.SizeX, src.SizeY, func(dst *gc.GpuTexture) error {
+		body := strings.ReplaceAll(medianKernel, "MEDIAN_RADIUS", fmt.Sprint(size/2))
+		return p.run(body, src, dst, border, nil)
+	})
+}
+
+// Sobel Gradient of luminance, or of first channel for one and two channel textures
+// dst receives gx, gy, magnitude and angle, nil allocates 4 channel FLOAT32 texture
+func (p *Imgproc) Sobel(src, dst *gc.GpuTexture, border Border) (*gc.GpuTexture, error) {
+	return p.apply(src, dst, gc.FLOAT32, 4, src.SizeX, src.SizeY, func(dst *gc.GpuTexture) error {
+		body := "const int channels = " + fmt.Sprint(src.Channels()) + ";\n" + sobelKernel
+		return p.run(body, src, dst, border, nil)
+	})
+}
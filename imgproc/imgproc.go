@@ -0,0 +1,198 @@
+// Package imgproc Image filters over 2D GpuTexture of normalized or float formats
+package imgproc
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/gl/all-core/gl"
+	"strings"
+)
+
+// Border Handling of samples outside image
+type Border int
+
+const (
+	// BorderClamp Repeating edge pixel, aaa|abcd|ddd
+	BorderClamp Border = iota
+	// BorderReflect Mirroring around edge pixel, dcb|abcd|cba
+	BorderReflect
+	// BorderZero Zero outside image
+	BorderZero
+)
+
+// localSize Work group side of every kernel
+const localSize = 16
+
+// Imgproc Cached kernels and scratch textures of one Computing
+// Operations use image units 0 and 1 and storage binding 0
+type Imgproc struct {
+	c        *gc.Computing
+	programs map[string]int
+	weights  *gc.GpuBuffer
+	scratch  map[string]*gc.GpuTexture
+}
+
+func New(c *gc.Computing) *Imgproc {
+	return &Imgproc{c: c, programs: make(map[string]int), scratch: make(map[string]*gc.GpuTexture)}
+}
+
+// Close Deleting kernels and scratch objects, textures returned by operations stay with caller
+func (p *Imgproc) Close() {
+	for _, id := range p.programs {
+		p.c.DeleteProgram(id)
+	}
+	for _, t := range p.scratch {
+		t.Close()
+	}
+	if p.weights != nil {
+		p.weights.Close()
+		p.weights = nil
+	}
+	p.programs = make(map[string]int)
+	p.scratch = make(map[string]*gc.GpuTexture)
+}
+
+// imageHeader Image declarations and border aware fetch shared by kernels
+const imageHeader = `
+layout(SRC_LAYOUT, binding = 0) readonly uniform image2D src;
+layout(DST_LAYOUT, binding = 1) writeonly uniform image2D dst;
+uniform ivec2 size;
+uniform ivec2 outSize;
+uniform int border;
+
+int borderCoord(int p, int n) {
+	if (border == 0 || n == 1) {
+		return clamp(p, 0, n - 1);
+	}
+	int period = 2 * (n - 1);
+	p = abs(p) % period;
+	return p < n ? p : period - p;
+}
+
+vec4 fetch(ivec2 p) {
+	if (border == 2 && (any(lessThan(p, ivec2(0))) || any(greaterThanEqual(p, size)))) {
+		return vec4(0.0);
+	}
+	return imageLoad(src, ivec2(borderCoord(p.x, size.x), borderCoord(p.y, size.y)));
+}
+layout(local_size_x = 16, local_size_y = 16, local_size_z = 1) in;
+`
+
+// imageLayout GLSL format qualifier of texture usable with image2D
+func imageLayout(t *gc.GpuTexture) (string, error) {
+	format, err := t.TextureFormat()
+	if err != nil {
+		return "", err
+	}
+	if !format.ImageLoadStore() {
+		return "", errors.New("imgproc: texture format " + t.Type().String() + " with " + fmt.Sprint(t.Channels()) + " channels is not supported by image load store")
+	}
+	if strings.HasSuffix(format.Layout, "i") {
+		return "", errors.New("imgproc: integer texture " + t.Type().String() + " is not supported")
+	}
+	return format.Layout, nil
+}
+
+// program Loading kernel body for formats of src and dst
+func (p *Imgproc) program(body string, src, dst *gc.GpuTexture) (int, error) {
+	srcLayout, err := imageLayout(src)
+	if err != nil {
+		return 0, err
+	}
+	dstLayout, err := imageLayout(dst)
+	if err != nil {
+		return 0, err
+	}
+	text := strings.NewReplacer("SRC_LAYOUT", srcLayout, "DST_LAYOUT", dstLayout).Replace(imageHeader) + body
+	if id, ok := p.programs[text]; ok {
+		return id, nil
+	}
+	id, err := p.c.LoadProgram(text)
+	if err != nil {
+		return 0, errors.New("imgproc: " + err.Error())
+	}
+	p.programs[text] = id
+	return id, nil
+}
+
+// like Texture of src type with channels and size, dst is returned when given
+func (p *Imgproc) like(src, dst *gc.GpuTexture, texType gc.TextureType, channels, width, height int) (*gc.GpuTexture, error) {
+	if dst != nil {
+		if dst.SizeX != width || dst.SizeY != height {
+			return nil, fmt.Errorf("imgproc: destination is %dx%d, expected %dx%d", dst.SizeX, dst.SizeY, width, height)
+		}
+		return dst, nil
+	}
+	dst = p.c.NewTexture(texType, channels)
+	if err := dst.Create2D(width, height); err != nil {
+		dst.Close()
+		return nil, err
+	}
+	return dst, nil
+}
+
+// apply Running op into dst, texture allocated by like is closed when op fails
+func (p *Imgproc) apply(src, dst *gc.GpuTexture, texType gc.TextureType, channels, width, height int, op func(dst *gc.GpuTexture) error) (*gc.GpuTexture, error) {
+	out, err := p.like(src, dst, texType, channels, width, height)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.c.DoErr(func() error {
+		return op(out)
+	}); err != nil {
+		if dst == nil {
+			out.Close()
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// temporary Float texture for intermediate pass, reused between calls
+func (p *Imgproc) temporary(channels, width, height int) (*gc.GpuTexture, error) {
+	key := fmt.Sprint(channels, width, height)
+	if t := p.scratch[key]; t != nil {
+		return t, nil
+	}
+	t := p.c.NewTexture(gc.FLOAT32, channels)
+	if err := t.Create2D(width, height); err != nil {
+		t.Close()
+		return nil, err
+	}
+	p.scratch[key] = t
+	return t, nil
+}
+
+// loadWeights Uploading filter weights to storage binding 0
+func (p *Imgproc) loadWeights(weights []float32) {
+	if p.weights == nil {
+		p.weights = p.c.NewBufferV(gc.BDynamicWrite, gc.BStorage)
+	}
+	p.weights.LoadFloat32(weights)
+	p.weights.SetBinding(0)
+}
+
+// run Binding images and running body over dst pixels, setup sets kernel uniforms
+func (p *Imgproc) run(body string, src, dst *gc.GpuTexture, border Border, setup func()) error {
+	program, err := p.program(body, src, dst)
+	if err != nil {
+		return err
+	}
+	p.c.UseProgram(program)
+	if err = src.SetBinding(0); err != nil {
+		return err
+	}
+	if err = dst.SetBinding(1); err != nil {
+		return err
+	}
+	p.c.SetInt("size", src.SizeX, src.SizeY)
+	p.c.SetInt("outSize", dst.SizeX, dst.SizeY)
+	p.c.SetInt("border", int(border))
+	if setup != nil {
+		setup()
+	}
+	p.c.Realize((dst.SizeX+localSize-1)/localSize, (dst.SizeY+localSize-1)/localSize, 1)
+	p.c.MemoryBarrier(gl.SHADER_IMAGE_ACCESS_BARRIER_BIT | gl.TEXTURE_UPDATE_BARRIER_BIT)
+	return nil
+}
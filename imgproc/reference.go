@@ -0,0 +1,52 @@
+package imgproc
+
+// borderIndex CPU version of border coordinate mapping, -1 for zero samples
+func borderIndex(p, n int, border Border) int {
+	if p >= 0 && p < n {
+		return p
+	}
+	switch border {
+	case BorderZero:
+		return -1
+	case BorderReflect:
+		if n == 1 {
+			return 0
+		}
+		period := 2 * (n - 1)
+		if p < 0 {
+			p = -p
+		}
+		p %= period
+		if p >= n {
+			p = period - p
+		}
+		return p
+	}
+	if p < 0 {
+		return 0
+	}
+	return n - 1
+}
+
+// ConvolveCPU Reference of Convolve on interleaved width by height image
+func ConvolveCPU(data []float32, width, height, channels int, kernel []float32, kernelWidth, kernelHeight int, border Border) []float32 {
+	out := make([]float32, len(data))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for ky := 0; ky < kernelHeight; ky++ {
+				sy := borderIndex(y+ky-kernelHeight/2, height, border)
+				for kx := 0; kx < kernelWidth; kx++ {
+					sx := borderIndex(x+kx-kernelWidth/2, width, border)
+					if sx < 0 || sy < 0 {
+						continue
+					}
+					weight := kernel[ky*kernelWidth+kx]
+					for c := 0; c < channels; c++ {
+						out[(y*width+x)*channels+c] += weight * data[(sy*width+sx)*channels+c]
+					}
+				}
+			}
+		}
+	}
+	return out
+}
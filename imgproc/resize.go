@@ -0,0 +1,60 @@
+package imgproc
+
+import (
+	"errors"
+	gc "github.com/eszdman/gocompute"
+)
+
+// Interpolation Sampling of Resize
+type Interpolation int
+
+const (
+	Nearest Interpolation = iota
+	Bilinear
+	// Bicubic Catmull-Rom spline over 4x4 neighbourhood
+	Bicubic
+)
+
+const resizeKernel = `
+uniform int interpolation;
+
+vec4 cubic(vec4 p0, vec4 p1, vec4 p2, vec4 p3, float t) {
+	return p1 + 0.5 * t * (p2 - p0 + t * (2.0 * p0 - 5.0 * p1 + 4.0 * p2 - p3 + t * (3.0 * (p1 - p2) + p3 - p0)));
+}
+` + pixelStart + `
+	vec2 scale = vec2(size) / vec2(outSize);
+	vec2 source = (vec2(pos) + 0.5) * scale - 0.5;
+	vec4 value;
+	if (interpolation == 0) {
+		value = fetch(ivec2(floor((vec2(pos) + 0.5) * scale)));
+	} else if (interpolation == 1) {
+		ivec2 base = ivec2(floor(source));
+		vec2 t = source - vec2(base);
+		vec4 top = mix(fetch(base), fetch(base + ivec2(1, 0)), t.x);
+		vec4 bottom = mix(fetch(base + ivec2(0, 1)), fetch(base + ivec2(1, 1)), t.x);
+		value = mix(top, bottom, t.y);
+	} else {
+		ivec2 base = ivec2(floor(source));
+		vec2 t = source - vec2(base);
+		vec4 rows[4];
+		for (int y = 0; y < 4; y++) {
+			ivec2 row = base + ivec2(0, y - 1);
+			rows[y] = cubic(fetch(row + ivec2(-1, 0)), fetch(row), fetch(row + ivec2(1, 0)), fetch(row + ivec2(2, 0)), t.x);
+		}
+		value = cubic(rows[0], rows[1], rows[2], rows[3], t.y);
+	}
+	imageStore(dst, pos, value);
+}
+`
+
+// Resize Scaling src to width by height, dst nil allocates texture of src type
+func (p *Imgproc) Resize(src, dst *gc.GpuTexture, width, height int, interpolation Interpolation, border Border) (*gc.GpuTexture, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("imgproc: resize to empty size")
+	}
+	return p.apply(src, dst, src.Type(), src.Channels(), width, height, func(dst *gc.GpuTexture) error {
+		return p.run(resizeKernel, src, dst, border, func() {
+			p.c.SetInt("interpolation", int(interpolation))
+		})
+	})
+}
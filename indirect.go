@@ -0,0 +1,149 @@
+package gocompute
+
+import (
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"strconv"
+)
+
+// indirectArgsProgram Writing work group counts for count invocations into dispatch buffer
+const indirectArgsProgram = `
+layout(std430, binding = 0) readonly buffer countBuffer {
+	uint counts[];
+};
+layout(std430, binding = 1) writeonly buffer dispatchBuffer {
+	uint dispatchArgs[];
+};
+uniform int countIndex;
+uniform int dispatchIndex;
+uniform int groupSize;
+uniform int maxGroups;
+layout(local_size_x = 1, local_size_y = 1, local_size_z = 1) in;
+void main() {
+	uint groups = (counts[countIndex] + uint(groupSize) - 1u) / uint(groupSize);
+	dispatchArgs[dispatchIndex] = min(groups, uint(maxGroups));
+	dispatchArgs[dispatchIndex + 1] = 1u;
+	dispatchArgs[dispatchIndex + 2] = 1u;
+}
+`
+
+// builtinProgram Loading library program once per Computing, user defines are not applied
+// Must run on GL thread
+func (c *Computing) builtinProgram(name, text string) (int, error) {
+	if id, ok := c.builtins[name]; ok {
+		return id, nil
+	}
+	defines := c.defineMap
+	c.defineMap = make(map[string]string)
+	id, err := c.loadProgram(text)
+	c.defineMap = defines
+	if err != nil {
+		return 0, errors.New(name + ": " + err.Error())
+	}
+	c.builtins[name] = id
+	return id, nil
+}
+
+// LocalSize Local work group size declared by program
+func (c *Computing) LocalSize(program int) (x, y, z int) {
+	c.run(func() {
+		x, y, z = 1, 1, 1
+		if group := c.computeGroups[program]; group != nil {
+			x, y, z = group.X, group.Y, group.Z
+		}
+	})
+	return x, y, z
+}
+
+// NewDispatchBuffer Buffer holding one set of work group counts, initialized to 1, 1, 1
+func (c *Computing) NewDispatchBuffer() *GpuBuffer {
+	buffer := c.NewBufferV(BDynamicCopy, BStorage)
+	BufferLoad(buffer, []uint32{1, 1, 1})
+	return buffer
+}
+
+func checkIndirect(buffer *GpuBuffer, offset int, name string) error {
+	if offset < 0 || offset%4 != 0 {
+		return errors.New(name + ": offset " + strconv.Itoa(offset) + " is not a non-negative multiple of 4")
+	}
+	if size := buffer.ByteSize(); offset+12 > size {
+		return errors.New(name + ": offset " + strconv.Itoa(offset) + " needs 12 bytes, buffer has " + strconv.Itoa(size))
+	}
+	return nil
+}
+
+// RealizeIndirect Running current program with work group counts read from buffer at byte offset
+// Buffer holds three uint32 values, writes of previous dispatches are made visible before reading
+func (c *Computing) RealizeIndirect(buffer *GpuBuffer, offset int) error {
+	if err := checkIndirect(buffer, offset, "RealizeIndirect"); err != nil {
+		return err
+	}
+	c.run(func() {
+		gl.MemoryBarrier(gl.COMMAND_BARRIER_BIT)
+		gl.BindBuffer(gl.DISPATCH_INDIRECT_BUFFER, buffer.id)
+		gl.DispatchComputeIndirect(offset)
+		gl.BindBuffer(gl.DISPATCH_INDIRECT_BUFFER, 0)
+		CheckErr("RealizeIndirect")
+	})
+	return nil
+}
+
+// IndirectFromCount Writing work group counts covering count invocations into dispatch buffer
+// count is uint32 at countOffset bytes written by previous kernel, groupSize is local_size_x of program dispatched next
+// Storage bindings 0 and 1 and current program are restored afterwards
+func (c *Computing) IndirectFromCount(count *GpuBuffer, countOffset int, dispatch *GpuBuffer, dispatchOffset int, groupSize int) (err error) {
+	if countOffset < 0 || countOffset%4 != 0 {
+		return errors.New("IndirectFromCount: count offset " + strconv.Itoa(countOffset) + " is not a non-negative multiple of 4")
+	}
+	if size := count.ByteSize(); countOffset+4 > size {
+		return errors.New("IndirectFromCount: count offset " + strconv.Itoa(countOffset) + " needs 4 bytes, buffer has " + strconv.Itoa(size))
+	}
+	if err = checkIndirect(dispatch, dispatchOffset, "IndirectFromCount"); err != nil {
+		return err
+	}
+	if groupSize <= 0 {
+		return errors.New("IndirectFromCount: group size must be positive")
+	}
+	c.run(func() {
+		var program int
+		program, err = c.builtinProgram("IndirectFromCount", indirectArgsProgram)
+		if err != nil {
+			return
+		}
+		previous := c.currentProgram
+		var bound [2]int32
+		for i := range bound {
+			gl.GetIntegeri_v(gl.SHADER_STORAGE_BUFFER_BINDING, uint32(i), &bound[i])
+		}
+		maxGroups := c.deviceInfo().MaxWorkGroupCount[0]
+		//Count was written by shader
+		gl.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		c.currentProgram = program
+		gl.UseProgram(c.programs[program])
+		count.SetBinding(0)
+		dispatch.SetBinding(1)
+		c.setInt("countIndex", countOffset/4)
+		c.setInt("dispatchIndex", dispatchOffset/4)
+		c.setInt("groupSize", groupSize)
+		c.setInt("maxGroups", maxGroups)
+		gl.DispatchCompute(1, 1, 1)
+		c.currentProgram = previous
+		gl.UseProgram(c.programs[previous])
+		for i := range bound {
+			gl.BindBufferBase(gl.SHADER_STORAGE_BUFFER, uint32(i), uint32(bound[i]))
+		}
+		CheckErr("IndirectFromCount")
+	})
+	return err
+}
+
+// RealizeCount Running program over count invocations read from GPU buffer without CPU readback
+// dispatch receives computed work group counts and can be reused between calls
+func (c *Computing) RealizeCount(program int, count *GpuBuffer, countOffset int, dispatch *GpuBuffer) error {
+	x, _, _ := c.LocalSize(program)
+	if err := c.IndirectFromCount(count, countOffset, dispatch, 0, x); err != nil {
+		return err
+	}
+	c.UseProgram(program)
+	return c.RealizeIndirect(dispatch, 0)
+}
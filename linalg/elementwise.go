@@ -0,0 +1,165 @@
+package linalg
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/all-core/gl"
+	"strings"
+)
+
+// transposeKernel Tiled copy through shared memory, padding avoids bank conflicts
+const transposeKernel = `
+layout(std430, binding = 0) readonly buffer matrixA {
+	float a[];
+};
+layout(std430, binding = 2) writeonly buffer matrixOut {
+	float outValues[];
+};
+uniform int rows;
+uniform int cols;
+uniform ivec2 strideA;
+uniform ivec2 strideOut;
+shared float tile[TILE][TILE + 1];
+layout(local_size_x = TILE, local_size_y = TILE, local_size_z = 1) in;
+void main() {
+	int x = int(gl_LocalInvocationID.x);
+	int y = int(gl_LocalInvocationID.y);
+	int row = int(gl_WorkGroupID.y) * TILE + y;
+	int col = int(gl_WorkGroupID.x) * TILE + x;
+	if (row < rows && col < cols) {
+		tile[y][x] = a[row * strideA.x + col * strideA.y];
+	}
+	barrier();
+	int outRow = int(gl_WorkGroupID.x) * TILE + y;
+	int outCol = int(gl_WorkGroupID.y) * TILE + x;
+	if (outRow < cols && outCol < rows) {
+		outValues[outRow * strideOut.x + outCol * strideOut.y] = tile[x][y];
+	}
+}
+`
+
+// Transpose out = A transposed, out is A.Cols by A.Rows in any layout
+func (l *Linalg) Transpose(a, out *Matrix) error {
+	if out.Rows != a.Cols || out.Cols != a.Rows {
+		return fmt.Errorf("linalg: Transpose of %dx%d into %dx%d", a.Rows, a.Cols, out.Rows, out.Cols)
+	}
+	return l.c.DoErr(func() error {
+		program, err := l.program(strings.ReplaceAll(transposeKernel, "TILE", fmt.Sprint(l.tile)))
+		if err != nil {
+			return err
+		}
+		l.c.UseProgram(program)
+		a.Buffer.SetBinding(0)
+		out.Buffer.SetBinding(2)
+		l.c.SetInt("rows", a.Rows)
+		l.c.SetInt("cols", a.Cols)
+		row, col := a.strides(false)
+		l.c.SetInt("strideA", row, col)
+		row, col = out.strides(false)
+		l.c.SetInt("strideOut", row, col)
+		l.c.Realize(groups(a.Cols, l.tile), groups(a.Rows, l.tile), 1)
+		l.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		return nil
+	})
+}
+
+// elementwiseKernel LINALG_OP is replaced by GLSL expression of a, b and alpha
+const elementwiseKernel = `
+layout(std430, binding = 0) readonly buffer matrixA {
+	float a[];
+};
+layout(std430, binding = 1) readonly buffer matrixB {
+	float b[];
+};
+layout(std430, binding = 2) writeonly buffer matrixOut {
+	float outValues[];
+};
+uniform int rows;
+uniform int cols;
+uniform ivec2 strideA;
+uniform ivec2 strideB;
+uniform ivec2 strideOut;
+uniform float alpha;
+layout(local_size_x = 16, local_size_y = 16, local_size_z = 1) in;
+void main() {
+	int col = int(gl_WorkGroupID.x) * 16 + int(gl_LocalInvocationID.x);
+	int row = int(gl_WorkGroupID.y) * 16 + int(gl_LocalInvocationID.y);
+	if (row < rows && col < cols) {
+		float x = a[row * strideA.x + col * strideA.y];
+		float y = b[row * strideB.x + col * strideB.y];
+		outValues[row * strideOut.x + col * strideOut.y] = LINALG_OP;
+	}
+}
+`
+
+// Elementwise out = expr for every element, expr is GLSL expression of x from A, y from B and alpha
+// Matrices may have different layouts, out may be A or B
+func (l *Linalg) Elementwise(expr string, alpha float32, a, b, out *Matrix) error {
+	if a.Rows != b.Rows || a.Cols != b.Cols || a.Rows != out.Rows || a.Cols != out.Cols {
+		return fmt.Errorf("linalg: elementwise %s of %dx%d and %dx%d into %dx%d", expr, a.Rows, a.Cols, b.Rows, b.Cols, out.Rows, out.Cols)
+	}
+	return l.c.DoErr(func() error {
+		program, err := l.program(strings.ReplaceAll(elementwiseKernel, "LINALG_OP", "("+expr+")"))
+		if err != nil {
+			return err
+		}
+		l.c.UseProgram(program)
+		a.Buffer.SetBinding(0)
+		b.Buffer.SetBinding(1)
+		out.Buffer.SetBinding(2)
+		l.c.SetInt("rows", a.Rows)
+		l.c.SetInt("cols", a.Cols)
+		row, col := a.strides(false)
+		l.c.SetInt("strideA", row, col)
+		row, col = b.strides(false)
+		l.c.SetInt("strideB", row, col)
+		row, col = out.strides(false)
+		l.c.SetInt("strideOut", row, col)
+		l.c.SetFloat32("alpha", alpha)
+		l.c.Realize(groups(a.Cols, 16), groups(a.Rows, 16), 1)
+		l.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		return nil
+	})
+}
+
+// Add out = A + B
+func (l *Linalg) Add(a, b, out *Matrix) error {
+	return l.Elementwise("x + y", 0, a, b, out)
+}
+
+// Sub out = A - B
+func (l *Linalg) Sub(a, b, out *Matrix) error {
+	return l.Elementwise("x - y", 0, a, b, out)
+}
+
+// Hadamard out = A * B by element
+func (l *Linalg) Hadamard(a, b, out *Matrix) error {
+	return l.Elementwise("x * y", 0, a, b, out)
+}
+
+// Div out = A / B by element
+func (l *Linalg) Div(a, b, out *Matrix) error {
+	return l.Elementwise("x / y", 0, a, b, out)
+}
+
+// Scale out = alpha * A
+func (l *Linalg) Scale(alpha float32, a, out *Matrix) error {
+	return l.Elementwise("alpha * x", alpha, a, a, out)
+}
+
+// Map out = expr of x from A, e.g. "max(x, 0.0)"
+func (l *Linalg) Map(expr string, a, out *Matrix) error {
+	return l.Elementwise(expr, 0, a, a, out)
+}
+
+// Axpy y = alpha * x + y
+func (l *Linalg) Axpy(alpha float32, x, y *Vector) error {
+	if x.Len != y.Len {
+		return fmt.Errorf("linalg: Axpy of %d and %d", x.Len, y.Len)
+	}
+	return l.Elementwise("alpha * x + y", alpha, x.Matrix(), y.Matrix(), y.Matrix())
+}
+
+// AxpyMatrix Y = alpha * X + Y
+func (l *Linalg) AxpyMatrix(alpha float32, x, y *Matrix) error {
+	return l.Elementwise("alpha * x + y", alpha, x, y, y)
+}
@@ -0,0 +1,166 @@
+package linalg
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/all-core/gl"
+	"strings"
+)
+
+// gemmKernel Tiled product, TILE is replaced by tile size
+const gemmKernel = `
+layout(std430, binding = 0) readonly buffer matrixA {
+	float a[];
+};
+layout(std430, binding = 1) readonly buffer matrixB {
+	float b[];
+};
+layout(std430, binding = 2) buffer matrixC {
+	float c[];
+};
+uniform int m;
+uniform int n;
+uniform int k;
+uniform ivec2 strideA;
+uniform ivec2 strideB;
+uniform ivec2 strideC;
+uniform float alpha;
+uniform float beta;
+shared float tileA[TILE][TILE + 1];
+shared float tileB[TILE][TILE + 1];
+layout(local_size_x = TILE, local_size_y = TILE, local_size_z = 1) in;
+void main() {
+	int x = int(gl_LocalInvocationID.x);
+	int y = int(gl_LocalInvocationID.y);
+	int col = int(gl_WorkGroupID.x) * TILE + x;
+	int row = int(gl_WorkGroupID.y) * TILE + y;
+	float sum = 0.0;
+	for (int t = 0; t < k; t += TILE) {
+		int ak = t + x;
+		int bk = t + y;
+		tileA[y][x] = row < m && ak < k ? a[row * strideA.x + ak * strideA.y] : 0.0;
+		tileB[y][x] = bk < k && col < n ? b[bk * strideB.x + col * strideB.y] : 0.0;
+		barrier();
+		for (int i = 0; i < TILE; i++) {
+			sum += tileA[y][i] * tileB[i][x];
+		}
+		barrier();
+	}
+	if (row < m && col < n) {
+		int index = row * strideC.x + col * strideC.y;
+		float previous = beta != 0.0 ? beta * c[index] : 0.0;
+		c[index] = alpha * sum + previous;
+	}
+}
+`
+
+// Gemm C = alpha * op(A) * op(B) + beta * C, op transposes when flag is set
+func (l *Linalg) Gemm(alpha float32, a *Matrix, transA bool, b *Matrix, transB bool, beta float32, c *Matrix) error {
+	m, k := a.shape(transA)
+	kb, n := b.shape(transB)
+	if k != kb || c.Rows != m || c.Cols != n {
+		return fmt.Errorf("linalg: Gemm of %dx%d and %dx%d into %dx%d", m, k, kb, n, c.Rows, c.Cols)
+	}
+	return l.c.DoErr(func() error {
+		program, err := l.program(strings.ReplaceAll(gemmKernel, "TILE", fmt.Sprint(l.tile)))
+		if err != nil {
+			return err
+		}
+		l.c.UseProgram(program)
+		a.Buffer.SetBinding(0)
+		b.Buffer.SetBinding(1)
+		c.Buffer.SetBinding(2)
+		l.c.SetInt("m", m)
+		l.c.SetInt("n", n)
+		l.c.SetInt("k", k)
+		row, col := a.strides(transA)
+		l.c.SetInt("strideA", row, col)
+		row, col = b.strides(transB)
+		l.c.SetInt("strideB", row, col)
+		row, col = c.strides(false)
+		l.c.SetInt("strideC", row, col)
+		l.c.SetFloat32("alpha", alpha)
+		l.c.SetFloat32("beta", beta)
+		l.c.Realize(groups(n, l.tile), groups(m, l.tile), 1)
+		l.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		return nil
+	})
+}
+
+// MatMul C = A * B
+func (l *Linalg) MatMul(a, b, c *Matrix) error {
+	return l.Gemm(1, a, false, b, false, 0, c)
+}
+
+// gemvKernel Work group per output element reducing dot product in shared memory
+const gemvKernel = `
+layout(std430, binding = 0) readonly buffer matrixA {
+	float a[];
+};
+layout(std430, binding = 1) readonly buffer vectorX {
+	float x[];
+};
+layout(std430, binding = 2) buffer vectorY {
+	float y[];
+};
+uniform int m;
+uniform int k;
+uniform ivec2 strideA;
+uniform float alpha;
+uniform float beta;
+shared float partial[256];
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+	int row = int(gl_WorkGroupID.y * gl_NumWorkGroups.x + gl_WorkGroupID.x);
+	if (row >= m) {
+		return;
+	}
+	uint local = gl_LocalInvocationID.x;
+	float sum = 0.0;
+	for (int i = int(local); i < k; i += 256) {
+		sum += a[row * strideA.x + i * strideA.y] * x[i];
+	}
+	partial[local] = sum;
+	barrier();
+	for (uint s = 128u; s > 0u; s >>= 1u) {
+		if (local < s) {
+			partial[local] += partial[local + s];
+		}
+		barrier();
+	}
+	if (local == 0u) {
+		float previous = beta != 0.0 ? beta * y[row] : 0.0;
+		y[row] = alpha * partial[0] + previous;
+	}
+}
+`
+
+// Gemv y = alpha * op(A) * x + beta * y
+func (l *Linalg) Gemv(alpha float32, a *Matrix, transA bool, x *Vector, beta float32, y *Vector) error {
+	m, k := a.shape(transA)
+	if x.Len != k || y.Len != m {
+		return fmt.Errorf("linalg: Gemv of %dx%d with %d into %d", m, k, x.Len, y.Len)
+	}
+	return l.c.DoErr(func() error {
+		program, err := l.program(gemvKernel)
+		if err != nil {
+			return err
+		}
+		l.c.UseProgram(program)
+		a.Buffer.SetBinding(0)
+		x.Buffer.SetBinding(1)
+		y.Buffer.SetBinding(2)
+		l.c.SetInt("m", m)
+		l.c.SetInt("k", k)
+		row, col := a.strides(transA)
+		l.c.SetInt("strideA", row, col)
+		l.c.SetFloat32("alpha", alpha)
+		l.c.SetFloat32("beta", beta)
+		if m <= 65535 {
+			l.c.Realize(m, 1, 1)
+		} else {
+			l.c.Realize(65535, groups(m, 65535), 1)
+		}
+		l.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		return nil
+	})
+}
@@ -0,0 +1,181 @@
+// Package linalg Dense float32 matrix kernels over GpuBuffer
+package linalg
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+	"strconv"
+)
+
+// Layout Element order of matrix storage
+type Layout int
+
+const (
+	RowMajor Layout = iota
+	ColMajor
+)
+
+// Matrix Rows by Cols float32 matrix stored in buffer
+type Matrix struct {
+	Rows, Cols int
+	Layout     Layout
+	Buffer     *gc.GpuBuffer
+}
+
+// Vector Float32 vector stored in buffer
+type Vector struct {
+	Len    int
+	Buffer *gc.GpuBuffer
+}
+
+// Linalg Cached kernels of one Computing, operations use storage bindings 0 to 2
+type Linalg struct {
+	c        *gc.Computing
+	programs map[string]int
+	tile     int
+}
+
+// tileSizes Candidate square tile sizes from largest
+var tileSizes = []int{32, 16, 8, 4}
+
+// New Selecting largest tile fitting device work group and shared memory limits
+func New(c *gc.Computing) *Linalg {
+	l := &Linalg{c: c, programs: make(map[string]int)}
+	device := c.DeviceInfo()
+	l.tile = tileFor(device.MaxWorkGroupInvocations, device.MaxWorkGroupSize[0], device.MaxWorkGroupSize[1], device.MaxSharedMemory)
+	return l
+}
+
+// tileFor Largest tile with tile*tile invocations and two float tiles in shared memory
+func tileFor(invocations, sizeX, sizeY, shared int) int {
+	for _, tile := range tileSizes {
+		if tile*tile <= invocations && tile <= sizeX && tile <= sizeY && 2*tile*(tile+1)*4 <= shared {
+			return tile
+		}
+	}
+	return tileSizes[len(tileSizes)-1]
+}
+
+// Tile Current tile size of GEMM and transpose
+func (l *Linalg) Tile() int {
+	return l.tile
+}
+
+// SetTile Overriding selected tile size
+func (l *Linalg) SetTile(tile int) error {
+	for _, size := range tileSizes {
+		if size == tile {
+			l.tile = tile
+			return nil
+		}
+	}
+	return errors.New("linalg: unsupported tile size " + strconv.Itoa(tile))
+}
+
+// program Loading kernel once per source
+func (l *Linalg) program(text string) (int, error) {
+	if id, ok := l.programs[text]; ok {
+		return id, nil
+	}
+	id, err := l.c.LoadProgram(text)
+	if err != nil {
+		return 0, errors.New("linalg: " + err.Error())
+	}
+	l.programs[text] = id
+	return id, nil
+}
+
+// Close Deleting kernels, matrices stay with caller
+func (l *Linalg) Close() {
+	for _, id := range l.programs {
+		l.c.DeleteProgram(id)
+	}
+	l.programs = make(map[string]int)
+}
+
+// NewMatrix Allocating uninitialized matrix
+func (l *Linalg) NewMatrix(rows, cols int, layout Layout) *Matrix {
+	m := &Matrix{Rows: rows, Cols: cols, Layout: layout, Buffer: l.c.NewBufferV(gc.BDynamicCopy, gc.BStorage)}
+	m.Buffer.AllocateFloat32(rows * cols)
+	return m
+}
+
+// MatrixFrom Uploading data stored in layout order
+func (l *Linalg) MatrixFrom(rows, cols int, layout Layout, data []float32) (*Matrix, error) {
+	if len(data) != rows*cols {
+		return nil, fmt.Errorf("linalg: %dx%d matrix from %d values", rows, cols, len(data))
+	}
+	m := &Matrix{Rows: rows, Cols: cols, Layout: layout, Buffer: l.c.NewBufferV(gc.BDynamicCopy, gc.BStorage)}
+	m.Buffer.LoadFloat32(data)
+	return m, nil
+}
+
+// NewVector Allocating uninitialized vector
+func (l *Linalg) NewVector(length int) *Vector {
+	v := &Vector{Len: length, Buffer: l.c.NewBufferV(gc.BDynamicCopy, gc.BStorage)}
+	v.Buffer.AllocateFloat32(length)
+	return v
+}
+
+// VectorFrom Uploading values
+func (l *Linalg) VectorFrom(data []float32) *Vector {
+	v := &Vector{Len: len(data), Buffer: l.c.NewBufferV(gc.BDynamicCopy, gc.BStorage)}
+	v.Buffer.LoadFloat32(data)
+	return v
+}
+
+// Read Values in storage layout order
+func (m *Matrix) Read() []float32 {
+	return m.Buffer.ReadFloat32(m.Rows * m.Cols)
+}
+
+// ReadRowMajor Values in row major order regardless of layout
+func (m *Matrix) ReadRowMajor() []float32 {
+	data := m.Read()
+	if m.Layout == RowMajor {
+		return data
+	}
+	return TransposeCPU(m.Cols, m.Rows, data)
+}
+
+func (m *Matrix) Close() {
+	m.Buffer.Close()
+}
+
+// Matrix Column matrix view sharing storage
+func (v *Vector) Matrix() *Matrix {
+	return &Matrix{Rows: v.Len, Cols: 1, Layout: RowMajor, Buffer: v.Buffer}
+}
+
+func (v *Vector) Read() []float32 {
+	return v.Buffer.ReadFloat32(v.Len)
+}
+
+func (v *Vector) Close() {
+	v.Buffer.Close()
+}
+
+// strides Row and column steps of element (i, j), transposed matrix swaps them
+func (m *Matrix) strides(transpose bool) (int, int) {
+	row, col := m.Cols, 1
+	if m.Layout == ColMajor {
+		row, col = 1, m.Rows
+	}
+	if transpose {
+		return col, row
+	}
+	return row, col
+}
+
+// shape Rows and columns of matrix or its transpose
+func (m *Matrix) shape(transpose bool) (int, int) {
+	if transpose {
+		return m.Cols, m.Rows
+	}
+	return m.Rows, m.Cols
+}
+
+func groups(size, local int) int {
+	return (size + local - 1) / local
+}
@@ -0,0 +1,36 @@
+package linalg
+
+// GemmCPU Reference of Gemm on row major slices, a is m by k, b is k by n, c is m by n
+func GemmCPU(m, n, k int, alpha float32, a, b []float32, beta float32, c []float32) {
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			sum := float32(0)
+			for p := 0; p < k; p++ {
+				sum += a[i*k+p] * b[p*n+j]
+			}
+			c[i*n+j] = alpha*sum + beta*c[i*n+j]
+		}
+	}
+}
+
+// GemvCPU Reference of Gemv on row major m by k matrix
+func GemvCPU(m, k int, alpha float32, a, x []float32, beta float32, y []float32) {
+	for i := 0; i < m; i++ {
+		sum := float32(0)
+		for p := 0; p < k; p++ {
+			sum += a[i*k+p] * x[p]
+		}
+		y[i] = alpha*sum + beta*y[i]
+	}
+}
+
+// TransposeCPU Transposing row major rows by cols matrix
+func TransposeCPU(rows, cols int, a []float32) []float32 {
+	out := make([]float32, len(a))
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			out[j*rows+i] = a[i*cols+j]
+		}
+	}
+	return out
+}
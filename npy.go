@@ -0,0 +1,566 @@
+package gocompute
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+const npyMagic = "\x93NUMPY"
+
+// NumPy dtypes of client data for texture types
+var npyTextureTypes = map[TextureType]string{
+	SIGNED8:    "|i1",
+	UNSIGNED8:  "|u1",
+	SIMPLE8:    "|u1",
+	SIGNED16:   "<i2",
+	UNSIGNED16: "<u2",
+	SIMPLE16:   "<u2",
+	FLOAT16:    "<f2",
+	SIGNED32:   "<i4",
+	UNSIGNED32: "<u4",
+	FLOAT32:    "<f4",
+}
+
+// Texture types chosen for dtypes when loading without explicit type
+var npyDefaultTypes = map[string]TextureType{
+	"i1": SIGNED8,
+	"u1": SIMPLE8,
+	"i2": SIGNED16,
+	"u2": SIMPLE16,
+	"i4": SIGNED32,
+	"u4": UNSIGNED32,
+	"f4": FLOAT32,
+}
+
+// npyDescr NumPy dtype and components count of Go element type
+// Arrays and structs of single numeric type map to trailing dimension
+func npyDescr(t reflect.Type) (string, int, error) {
+	if t == halfType {
+		return "<f2", 1, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "|b1", 1, nil
+	case reflect.Int8:
+		return "|i1", 1, nil
+	case reflect.Uint8:
+		return "|u1", 1, nil
+	case reflect.Int16:
+		return "<i2", 1, nil
+	case reflect.Uint16:
+		return "<u2", 1, nil
+	case reflect.Int32:
+		return "<i4", 1, nil
+	case reflect.Uint32:
+		return "<u4", 1, nil
+	case reflect.Int64:
+		return "<i8", 1, nil
+	case reflect.Uint64:
+		return "<u8", 1, nil
+	case reflect.Float32:
+		return "<f4", 1, nil
+	case reflect.Float64:
+		return "<f8", 1, nil
+	case reflect.Array:
+		descr, count, err := npyDescr(t.Elem())
+		return descr, count * t.Len(), err
+	case reflect.Struct:
+		descr, total := "", 0
+		for i := 0; i < t.NumField(); i++ {
+			fieldDescr, count, err := npyDescr(t.Field(i).Type)
+			if err != nil {
+				return "", 0, err
+			}
+			if descr != "" && fieldDescr != descr {
+				return "", 0, errors.New("npy: struct " + t.Name() + " mixes field types")
+			}
+			descr = fieldDescr
+			total += count
+		}
+		if int(t.Size()) != total*npyItemSize(descr) {
+			return "", 0, errors.New("npy: struct " + t.Name() + " has padding")
+		}
+		return descr, total, nil
+	}
+	return "", 0, errors.New("npy: unsupported element type " + t.String())
+}
+
+func npyItemSize(descr string) int {
+	size, _ := strconv.Atoi(descr[2:])
+	return size
+}
+
+// writeNPY Writing version 1.0 header followed by little endian data
+func writeNPY(w io.Writer, descr string, shape []int, data []byte) error {
+	dims := make([]string, len(shape))
+	for i, d := range shape {
+		dims[i] = strconv.Itoa(d)
+	}
+	shapeStr := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		shapeStr += ","
+	}
+	header := "{'descr': '" + descr + "', 'fortran_order': False, 'shape': (" + shapeStr + "), }"
+	//Header is padded so data starts at multiple of 64 bytes
+	total := len(npyMagic) + 4 + len(header) + 1
+	header += strings.Repeat(" ", (64-total%64)%64) + "\n"
+	prefix := make([]byte, 0, len(npyMagic)+4)
+	prefix = append(prefix, npyMagic...)
+	prefix = append(prefix, 1, 0)
+	prefix = binary.LittleEndian.AppendUint16(prefix, uint16(len(header)))
+	if _, err := w.Write(append(prefix, header...)); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readNPY Reading header and data, big endian data is swapped to little endian
+func readNPY(r io.Reader) (string, []int, []byte, error) {
+	br := bufio.NewReader(r)
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(br, prefix); err != nil {
+		return "", nil, nil, err
+	}
+	if string(prefix[:len(npyMagic)]) != npyMagic {
+		return "", nil, nil, errors.New("npy: wrong magic")
+	}
+	headerLen := 0
+	switch prefix[len(npyMagic)] {
+	case 1:
+		size := make([]byte, 2)
+		if _, err := io.ReadFull(br, size); err != nil {
+			return "", nil, nil, err
+		}
+		headerLen = int(binary.LittleEndian.Uint16(size))
+	case 2, 3:
+		size := make([]byte, 4)
+		if _, err := io.ReadFull(br, size); err != nil {
+			return "", nil, nil, err
+		}
+		headerLen = int(binary.LittleEndian.Uint32(size))
+	default:
+		return "", nil, nil, errors.New("npy: unsupported version " + strconv.Itoa(int(prefix[len(npyMagic)])))
+	}
+	headerBytes := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, headerBytes); err != nil {
+		return "", nil, nil, err
+	}
+	header := string(headerBytes)
+	descr, err := npyField(header, "descr")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	descr = strings.Trim(descr, "'\"")
+	if len(descr) < 3 {
+		return "", nil, nil, errors.New("npy: unsupported dtype " + descr)
+	}
+	order, err := npyField(header, "fortran_order")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if order != "False" {
+		return "", nil, nil, errors.New("npy: fortran order is not supported")
+	}
+	shapeStr, err := npyField(header, "shape")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	shape := make([]int, 0)
+	count := 1
+	for _, dim := range strings.Split(strings.Trim(shapeStr, "()"), ",") {
+		dim = strings.TrimSpace(dim)
+		if dim == "" {
+			continue
+		}
+		d, err := strconv.Atoi(dim)
+		if err != nil || d < 0 {
+			return "", nil, nil, errors.New("npy: wrong shape " + shapeStr)
+		}
+		if d != 0 && count > math.MaxInt/d {
+			return "", nil, nil, errors.New("npy: shape " + shapeStr + " is too large")
+		}
+		shape = append(shape, d)
+		count *= d
+	}
+	itemSize := npyItemSize(descr)
+	if itemSize == 0 {
+		return "", nil, nil, errors.New("npy: unsupported dtype " + descr)
+	}
+	if count > math.MaxInt/itemSize {
+		return "", nil, nil, errors.New("npy: shape " + shapeStr + " is too large")
+	}
+	//Header is untrusted, memory grows with data actually present instead of declared size
+	size := count * itemSize
+	data, err := io.ReadAll(io.LimitReader(br, int64(size)))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(data) != size {
+		return "", nil, nil, errors.New("npy: data has " + strconv.Itoa(len(data)) + " bytes, shape " + shapeStr + " needs " + strconv.Itoa(size))
+	}
+	if descr[0] == '>' && itemSize > 1 {
+		for i := 0; i < len(data); i += itemSize {
+			for a, b := i, i+itemSize-1; a < b; a, b = a+1, b-1 {
+				data[a], data[b] = data[b], data[a]
+			}
+		}
+	}
+	//Store byte order independent kind and size, e.g. "<f4"
+	descr = "<" + descr[1:]
+	if itemSize == 1 {
+		descr = "|" + descr[1:]
+	}
+	return descr, shape, data, nil
+}
+
+// npyField Extracting raw value of key from header dict
+func npyField(header, key string) (string, error) {
+	start := strings.Index(header, "'"+key+"'")
+	if start < 0 {
+		return "", errors.New("npy: header misses " + key)
+	}
+	value := strings.TrimSpace(header[start+len(key)+2:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, ":"))
+	end := 0
+	if strings.HasPrefix(value, "(") {
+		end = strings.Index(value, ")") + 1
+	} else {
+		end = strings.IndexAny(value, ",}")
+	}
+	if end <= 0 {
+		return "", errors.New("npy: malformed " + key)
+	}
+	return strings.TrimSpace(value[:end]), nil
+}
+
+// WriteNPY Writing slice as npy array, shape defaults to [len(data)] plus components of V
+func WriteNPY[V any](w io.Writer, data []V, shape ...int) error {
+	descr, components, err := npyDescr(reflect.TypeOf(data).Elem())
+	if err != nil {
+		return err
+	}
+	if len(shape) == 0 {
+		shape = []int{len(data)}
+		if components > 1 {
+			shape = append(shape, components)
+		}
+	}
+	count := 1
+	for _, d := range shape {
+		count *= d
+	}
+	if count != len(data)*components {
+		return errors.New("npy: shape doesn't match " + strconv.Itoa(len(data)*components) + " values")
+	}
+	var raw []byte
+	if len(data) > 0 {
+		raw = toSlice[byte](unsafe.Pointer(&data[0]), len(data)*tSize[V]())
+	}
+	return writeNPY(w, descr, shape, raw)
+}
+
+// ReadNPY Reading npy array of matching dtype into new slice
+func ReadNPY[V any](r io.Reader) ([]V, []int, error) {
+	descr, components, err := npyDescr(reflect.TypeOf((*V)(nil)).Elem())
+	if err != nil {
+		return nil, nil, err
+	}
+	fileDescr, shape, data, err := readNPY(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fileDescr != descr {
+		return nil, nil, errors.New("npy: dtype " + fileDescr + " doesn't match " + descr)
+	}
+	size := tSize[V]()
+	if len(data)%size != 0 || (components > 1 && (len(shape) == 0 || shape[len(shape)-1]%components != 0)) {
+		return nil, nil, errors.New("npy: data doesn't divide into elements of " + strconv.Itoa(components) + " components")
+	}
+	out := make([]V, len(data)/size)
+	if len(out) > 0 {
+		copy(toSlice[byte](unsafe.Pointer(&out[0]), len(data)), data)
+	}
+	return out, shape, nil
+}
+
+// WriteHostImageNPY Writing image as npy array of shape [Z,Y,X,channels]
+func WriteHostImageNPY(w io.Writer, img *HostImage) error {
+	if err := img.validate(); err != nil {
+		return err
+	}
+	descr, ok := npyTextureTypes[img.Type]
+	if !ok {
+		return errors.New("npy: texture type " + img.Type.String() + " has no dtype")
+	}
+	data := img.Data
+	if img.Type == FLOAT16 && len(data) > 0 {
+		//Float32 client data of FLOAT16 textures is narrowed to half arrays
+		narrow := ToHalf(toSlice[float32](unsafe.Pointer(&data[0]), len(data)/4))
+		data = toSlice[byte](unsafe.Pointer(&narrow[0]), len(narrow)*2)
+	}
+	return writeNPY(w, descr, []int{img.Depth, img.Height, img.Width, img.Channels}, data)
+}
+
+// ReadHostImageNPY Reading npy array of shape [Z,Y,X,C], [Y,X,C] or [Y,X] into image
+// texType NONE selects type from dtype
+func ReadHostImageNPY(r io.Reader, texType TextureType) (*HostImage, error) {
+	descr, shape, data, err := readNPY(r)
+	if err != nil {
+		return nil, err
+	}
+	if descr == "<f2" {
+		//Half arrays are widened to float32 client data of FLOAT16 textures
+		if len(data) > 0 {
+			wide := FromHalf(toSlice[Half](unsafe.Pointer(&data[0]), len(data)/2))
+			data = toSlice[byte](unsafe.Pointer(&wide[0]), len(wide)*4)
+		}
+		descr = "<f4"
+		if texType == NONE {
+			texType = FLOAT16
+		}
+	}
+	if texType == NONE {
+		texType = npyDefaultTypes[descr[1:]]
+	}
+	if npyTextureTypes[texType] != descr && !(texType == FLOAT16 && descr == "<f4") {
+		return nil, errors.New("npy: dtype " + descr + " can't be loaded as " + texType.String())
+	}
+	img := &HostImage{Depth: 1, Channels: 1, Type: texType, Data: data}
+	switch len(shape) {
+	case 2:
+		img.Height, img.Width = shape[0], shape[1]
+	case 3:
+		img.Height, img.Width, img.Channels = shape[0], shape[1], shape[2]
+	case 4:
+		img.Depth, img.Height, img.Width, img.Channels = shape[0], shape[1], shape[2], shape[3]
+	default:
+		return nil, errors.New("npy: image requires 2 to 4 dimensions, got " + strconv.Itoa(len(shape)))
+	}
+	if err = img.validate(); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// readBufferAll Whole storage of buffer as elements of V
+func readBufferAll[V any](b *GpuBuffer) ([]V, error) {
+	bytes, size := b.ByteSize(), tSize[V]()
+	if bytes%size != 0 {
+		return nil, errors.New("npy: buffer of " + strconv.Itoa(bytes) + " bytes doesn't divide into elements of " + strconv.Itoa(size) + " bytes")
+	}
+	data := make([]V, bytes/size)
+	BufferReadInto(b, data, 0)
+	return data, nil
+}
+
+// SaveNPY Writing whole buffer as V to npy file
+func SaveNPY[V any](path string, b *GpuBuffer) error {
+	data, err := readBufferAll[V](b)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = WriteNPY(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadNPY Loading npy file with dtype of V into buffer
+func LoadNPY[V any](path string, b *GpuBuffer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return readBufferNPY[V](file, b)
+}
+
+func readBufferNPY[V any](r io.Reader, b *GpuBuffer) error {
+	data, _, err := ReadNPY[V](r)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return errors.New("npy: empty array")
+	}
+	BufferLoad(b, data)
+	return nil
+}
+
+// SaveTextureNPY Writing texture to npy file with shape [Z,Y,X,channels]
+func SaveTextureNPY(path string, t *GpuTexture) error {
+	img, err := TextureToHost(t)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = WriteHostImageNPY(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadTextureNPY Loading npy file into new texture, texType NONE selects type from dtype
+func (c *Computing) LoadTextureNPY(path string, texType TextureType) (*GpuTexture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := ReadHostImageNPY(file, texType)
+	if err != nil {
+		return nil, err
+	}
+	return c.TextureFromHost(img)
+}
+
+// NPZWriter Bundle of npy arrays in zip archive, compatible with numpy.load
+type NPZWriter struct {
+	file *os.File
+	zip  *zip.Writer
+}
+
+// CreateNPZ Creating npz bundle at path
+func CreateNPZ(path string) (*NPZWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &NPZWriter{file, zip.NewWriter(file)}, nil
+}
+
+func (z *NPZWriter) create(name string) (io.Writer, error) {
+	return z.zip.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+}
+
+// NPZWriteSlice Adding slice as array name
+func NPZWriteSlice[V any](z *NPZWriter, name string, data []V, shape ...int) error {
+	w, err := z.create(name)
+	if err != nil {
+		return err
+	}
+	return WriteNPY(w, data, shape...)
+}
+
+// NPZSaveBuffer Adding whole buffer as array name
+func NPZSaveBuffer[V any](z *NPZWriter, name string, b *GpuBuffer) error {
+	data, err := readBufferAll[V](b)
+	if err != nil {
+		return err
+	}
+	return NPZWriteSlice(z, name, data)
+}
+
+// SaveTexture Adding texture as array name with shape [Z,Y,X,channels]
+func (z *NPZWriter) SaveTexture(name string, t *GpuTexture) error {
+	img, err := TextureToHost(t)
+	if err != nil {
+		return err
+	}
+	w, err := z.create(name)
+	if err != nil {
+		return err
+	}
+	return WriteHostImageNPY(w, img)
+}
+
+func (z *NPZWriter) Close() error {
+	if err := z.zip.Close(); err != nil {
+		z.file.Close()
+		return err
+	}
+	return z.file.Close()
+}
+
+// NPZReader Opened npz bundle
+type NPZReader struct {
+	zip   *zip.ReadCloser
+	files map[string]*zip.File
+}
+
+// OpenNPZ Opening npz bundle, both stored and deflated archives are supported
+func OpenNPZ(path string) (*NPZReader, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	z := &NPZReader{reader, make(map[string]*zip.File)}
+	for _, f := range reader.File {
+		z.files[strings.TrimSuffix(f.Name, ".npy")] = f
+	}
+	return z, nil
+}
+
+// Names Array names in bundle
+func (z *NPZReader) Names() []string {
+	names := make([]string, 0, len(z.files))
+	for _, f := range z.zip.File {
+		names = append(names, strings.TrimSuffix(f.Name, ".npy"))
+	}
+	return names
+}
+
+func (z *NPZReader) open(name string) (io.ReadCloser, error) {
+	f, ok := z.files[name]
+	if !ok {
+		return nil, errors.New("npz: array " + name + " not found")
+	}
+	return f.Open()
+}
+
+// NPZReadSlice Reading array name into new slice
+func NPZReadSlice[V any](z *NPZReader, name string) ([]V, []int, error) {
+	r, err := z.open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	return ReadNPY[V](r)
+}
+
+// NPZLoadBuffer Loading array name into buffer
+func NPZLoadBuffer[V any](z *NPZReader, name string, b *GpuBuffer) error {
+	r, err := z.open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return readBufferNPY[V](r, b)
+}
+
+// LoadTexture Loading array name into new texture, texType NONE selects type from dtype
+func (z *NPZReader) LoadTexture(c *Computing, name string, texType TextureType) (*GpuTexture, error) {
+	r, err := z.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	img, err := ReadHostImageNPY(r, texType)
+	if err != nil {
+		return nil, err
+	}
+	return c.TextureFromHost(img)
+}
+
+func (z *NPZReader) Close() error {
+	return z.zip.Close()
+}
@@ -0,0 +1,142 @@
+package gocompute
+
+import (
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// PingPong Two buffers or textures swapped after every dispatch of iterative program
+// Program reads current object at input binding and writes other one at output binding
+type PingPong struct {
+	c             *Computing
+	buffers       [2]*GpuBuffer
+	textures      [2]*GpuTexture
+	current       int
+	steps         int
+	program       int
+	inputBinding  int
+	outputBinding int
+	uniforms      []func(c *Computing, step int)
+	x, y, z       int
+	// threads x, y and z count invocations and are divided by local size of program
+	threads bool
+}
+
+// NewBufferPingPong Ping-pong over buffers of n elements, current holds initial data
+func (c *Computing) NewBufferPingPong(current, other *GpuBuffer, n, program, inputBinding, outputBinding int) *PingPong {
+	return &PingPong{c: c, buffers: [2]*GpuBuffer{current, other}, program: program,
+		inputBinding: inputBinding, outputBinding: outputBinding, x: n, y: 1, z: 1, threads: true}
+}
+
+// NewTexturePingPong Ping-pong over image textures, current holds initial data
+func (c *Computing) NewTexturePingPong(current, other *GpuTexture, program, inputBinding, outputBinding int) *PingPong {
+	return &PingPong{c: c, textures: [2]*GpuTexture{current, other}, program: program,
+		inputBinding: inputBinding, outputBinding: outputBinding, x: current.SizeX, y: current.SizeY, z: current.SizeZ, threads: true}
+}
+
+// Dispatch Work group counts of every step
+// By default buffer elements or texture dimensions are covered by work groups of program local size
+func (p *PingPong) Dispatch(x, y, z int) *PingPong {
+	p.x, p.y, p.z = x, y, z
+	p.threads = false
+	return p
+}
+
+func (p *PingPong) SetInt(name string, input ...int) *PingPong {
+	return p.Uniform(func(c *Computing, step int) {
+		c.SetInt(name, input...)
+	})
+}
+
+func (p *PingPong) SetFloat32(name string, input ...float32) *PingPong {
+	return p.Uniform(func(c *Computing, step int) {
+		c.SetFloat32(name, input...)
+	})
+}
+
+// Uniform Custom uniform setter called before every step with total step number
+func (p *PingPong) Uniform(set func(c *Computing, step int)) *PingPong {
+	p.uniforms = append(p.uniforms, set)
+	return p
+}
+
+func (p *PingPong) isTexture() bool {
+	return p.textures[0] != nil
+}
+
+// Step Running program n times, swapping input and output after every dispatch
+func (p *PingPong) Step(n int) (err error) {
+	if n < 0 {
+		return errors.New("PingPong: negative step count")
+	}
+	p.c.run(func() {
+		barrier := uint32(gl.SHADER_STORAGE_BARRIER_BIT)
+		final := uint32(gl.BUFFER_UPDATE_BARRIER_BIT)
+		if p.isTexture() {
+			barrier = gl.SHADER_IMAGE_ACCESS_BARRIER_BIT
+			final = gl.TEXTURE_UPDATE_BARRIER_BIT | gl.PIXEL_BUFFER_BARRIER_BIT
+		}
+		p.c.UseProgram(p.program)
+		x, y, z := p.x, p.y, p.z
+		if group := p.c.computeGroups[p.program]; p.threads && group != nil {
+			x, y, z = (x+group.X-1)/group.X, (y+group.Y-1)/group.Y, (z+group.Z-1)/group.Z
+		}
+		for i := 0; i < n; i++ {
+			if p.isTexture() {
+				if err = p.textures[p.current].SetBinding(p.inputBinding); err != nil {
+					return
+				}
+				if err = p.textures[1-p.current].SetBinding(p.outputBinding); err != nil {
+					return
+				}
+			} else {
+				p.buffers[p.current].SetBinding(p.inputBinding)
+				p.buffers[1-p.current].SetBinding(p.outputBinding)
+			}
+			for _, set := range p.uniforms {
+				set(p.c, p.steps)
+			}
+			p.c.Realize(x, y, z)
+			//Next step reads what this one wrote
+			p.c.MemoryBarrier(barrier)
+			p.current = 1 - p.current
+			p.steps++
+		}
+		if n > 0 {
+			p.c.MemoryBarrier(final)
+		}
+		CheckErr("PingPong.Step")
+	})
+	return err
+}
+
+// Steps Total steps run since creation or Reset
+func (p *PingPong) Steps() int {
+	return p.steps
+}
+
+// Reset Making first object current again and zeroing step counter
+func (p *PingPong) Reset() {
+	p.current = 0
+	p.steps = 0
+}
+
+// Current Buffer holding latest result
+func (p *PingPong) Current() *GpuBuffer {
+	return p.buffers[p.current]
+}
+
+// Previous Buffer holding result of step before latest
+func (p *PingPong) Previous() *GpuBuffer {
+	return p.buffers[1-p.current]
+}
+
+// CurrentTexture Texture holding latest result
+func (p *PingPong) CurrentTexture() *GpuTexture {
+	return p.textures[p.current]
+}
+
+// PreviousTexture Texture holding result of step before latest
+func (p *PingPong) PreviousTexture() *GpuTexture {
+	return p.textures[1-p.current]
+}
@@ -0,0 +1,119 @@
+package primitives
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+const flagsKernel = `
+layout(std430, binding = 0) readonly buffer primInput {
+	PRIM_T inputValues[];
+};
+layout(std430, binding = 1) writeonly buffer primFlags {
+	uint flags[];
+};
+uniform int n;
+uniform int blocks;
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+` + blockIndex + `
+	uint i = block * 256u + gl_LocalInvocationID.x;
+	if (i < uint(n)) {
+		flags[i] = primKeep(inputValues[i]) ? 1u : 0u;
+	}
+}
+`
+
+const scatterKernel = `
+layout(std430, binding = 0) readonly buffer primInput {
+	PRIM_T inputValues[];
+};
+layout(std430, binding = 1) readonly buffer primFlags {
+	uint flags[];
+};
+layout(std430, binding = 2) readonly buffer primPositions {
+	uint positions[];
+};
+layout(std430, binding = 3) writeonly buffer primOutput {
+	PRIM_T outputValues[];
+};
+layout(std430, binding = 4) writeonly buffer primCount {
+	uint compactCount[];
+};
+uniform int n;
+uniform int blocks;
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+` + blockIndex + `
+	uint i = block * 256u + gl_LocalInvocationID.x;
+	if (i < uint(n)) {
+		if (flags[i] != 0u) {
+			outputValues[positions[i]] = inputValues[i];
+		}
+		if (i == uint(n) - 1u) {
+			compactCount[0] = positions[i] + flags[i];
+		}
+	}
+}
+`
+
+// CompactTo Copying elements of input matching predicate into output keeping order
+// predicate is GLSL bool expression of x, number of kept elements is written as uint32 into count
+func CompactTo[T Element](p *Primitives, input, output *gc.GpuBuffer, n int, predicate string, count *gc.GpuBuffer) error {
+	if err := checkSize("Compact", n, input, output); err != nil {
+		return err
+	}
+	if err := checkSize("Compact count", 1, count); err != nil {
+		return err
+	}
+	return p.c.DoErr(func() error {
+		if n == 0 {
+			gc.BufferPartialLoad(count, []uint32{0}, 0)
+			return nil
+		}
+		elementHeader := header(glslType[T](), Sum) + "bool primKeep(PRIM_T x) {\n\treturn " + predicate + ";\n}\n"
+		flagsProgram, err := p.program(kernel(elementHeader, flagsKernel))
+		if err != nil {
+			return err
+		}
+		scatterProgram, err := p.program(kernel(elementHeader, scatterKernel))
+		if err != nil {
+			return err
+		}
+		blocks := (n + groupSize - 1) / groupSize
+		flags := p.buffer("compactFlags", n*4)
+		positions := p.buffer("compactPositions", n*4)
+		p.c.UseProgram(flagsProgram)
+		input.SetBinding(0)
+		flags.SetBinding(1)
+		p.c.SetInt("n", n)
+		p.dispatch(blocks)
+		if err = p.scan(header("uint", Sum), flags, positions, n, true, 0); err != nil {
+			return err
+		}
+		p.c.UseProgram(scatterProgram)
+		input.SetBinding(0)
+		flags.SetBinding(1)
+		positions.SetBinding(2)
+		output.SetBinding(3)
+		count.SetBinding(4)
+		p.c.SetInt("n", n)
+		p.dispatch(blocks)
+		return nil
+	})
+}
+
+// Compact Copying elements of input matching predicate into output, returns number of kept elements
+func Compact[T Element](p *Primitives, input, output *gc.GpuBuffer, n int, predicate string) (int, error) {
+	result := make([]uint32, 1)
+	err := p.c.DoErr(func() error {
+		count := p.buffer("compactCount", 4)
+		if err := CompactTo[T](p, input, output, n, predicate, count); err != nil {
+			return err
+		}
+		p.c.MemoryBarrier(gl.BUFFER_UPDATE_BARRIER_BIT)
+		gc.BufferReadInto(count, result, 0)
+		return nil
+	})
+	return int(result[0]), err
+}
@@ -0,0 +1,172 @@
+// Package primitives Parallel reduce, scan and compaction over GpuBuffer of int32, uint32 and float32
+package primitives
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/gl/all-core/gl"
+	"strings"
+)
+
+// groupSize Invocations per work group, every invocation handles two elements
+const groupSize = 256
+const blockSize = 2 * groupSize
+
+// maxGroups Work group limit of single dispatch dimension guaranteed by GL
+const maxGroups = 65535
+
+// Element Supported element types
+type Element interface {
+	int32 | uint32 | float32
+}
+
+func glslType[T Element]() string {
+	var zero T
+	switch any(zero).(type) {
+	case int32:
+		return "int"
+	case uint32:
+		return "uint"
+	}
+	return "float"
+}
+
+// Operator Associative binary GLSL operator with identity element
+type Operator struct {
+	Name string
+	// Expr GLSL expression combining a and b
+	Expr     string
+	identity func(glslType string) string
+}
+
+// Identity GLSL literal of identity element for type name int, uint or float
+func (o Operator) Identity(glslType string) string {
+	return o.identity(glslType)
+}
+
+var Sum = Operator{"sum", "a + b", func(t string) string {
+	return t + "(0)"
+}}
+
+var Min = Operator{"min", "min(a, b)", func(t string) string {
+	switch t {
+	case "int":
+		return "int(0x7FFFFFFF)"
+	case "uint":
+		return "0xFFFFFFFFu"
+	}
+	return "uintBitsToFloat(0x7F800000u)"
+}}
+
+var Max = Operator{"max", "max(a, b)", func(t string) string {
+	switch t {
+	case "int":
+		return "int(0x80000000)"
+	case "uint":
+		return "0u"
+	}
+	return "uintBitsToFloat(0xFF800000u)"
+}}
+
+// Custom Operator from GLSL expression of a and b and identity literal
+// Reduce also requires commutativity, Scan keeps order of elements
+func Custom(name, expr, identity string) Operator {
+	return Operator{name, expr, func(string) string {
+		return identity
+	}}
+}
+
+// Primitives Cached programs and scratch buffers of one Computing
+// Operations use storage bindings 0 to 4 and change current program
+type Primitives struct {
+	c        *gc.Computing
+	programs map[string]int
+	scratch  map[string]*gc.GpuBuffer
+}
+
+func New(c *gc.Computing) *Primitives {
+	return &Primitives{c: c, programs: make(map[string]int), scratch: make(map[string]*gc.GpuBuffer)}
+}
+
+// header Element type and operator definitions shared by kernels
+func header(glslType string, op Operator) string {
+	return "#define PRIM_T " + glslType + "\n" +
+		"#define PRIM_IDENTITY (" + op.Identity(glslType) + ")\n" +
+		"PRIM_T primOp(PRIM_T a, PRIM_T b) {\n\treturn " + op.Expr + ";\n}\n"
+}
+
+// program Loading kernel once per source
+func (p *Primitives) program(text string) (int, error) {
+	if id, ok := p.programs[text]; ok {
+		return id, nil
+	}
+	id, err := p.c.LoadProgram(text)
+	if err != nil {
+		return 0, errors.New("primitives: " + err.Error())
+	}
+	p.programs[text] = id
+	return id, nil
+}
+
+// buffer Scratch buffer of at least bytes size, reused between calls
+func (p *Primitives) buffer(name string, bytes int) *gc.GpuBuffer {
+	if bytes < 4 {
+		bytes = 4
+	}
+	b := p.scratch[name]
+	if b == nil {
+		b = p.c.NewBufferV(gc.BDynamicCopy, gc.BStorage)
+		p.scratch[name] = b
+	} else if b.ByteSize() >= bytes {
+		return b
+	}
+	gc.BufferAllocateBytes(b, bytes, 1)
+	return b
+}
+
+// dispatch Running blocks work groups, split over y above single dimension limit
+func (p *Primitives) dispatch(blocks int) {
+	p.c.SetInt("blocks", blocks)
+	if blocks <= maxGroups {
+		p.c.Realize(blocks, 1, 1)
+	} else {
+		p.c.Realize(maxGroups, (blocks+maxGroups-1)/maxGroups, 1)
+	}
+	p.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+}
+
+func checkSize(name string, n int, buffers ...*gc.GpuBuffer) error {
+	if n < 0 {
+		return errors.New("primitives: " + name + " of negative size")
+	}
+	for _, b := range buffers {
+		if b.ByteSize() < n*4 {
+			return fmt.Errorf("primitives: %s of %d elements, buffer has %d bytes", name, n, b.ByteSize())
+		}
+	}
+	return nil
+}
+
+// Close Deleting programs and scratch buffers
+func (p *Primitives) Close() {
+	for _, id := range p.programs {
+		p.c.DeleteProgram(id)
+	}
+	for _, b := range p.scratch {
+		b.Close()
+	}
+	p.programs = make(map[string]int)
+	p.scratch = make(map[string]*gc.GpuBuffer)
+}
+
+// blockIndex GLSL work group index of dispatch split over y
+const blockIndex = `	uint block = gl_WorkGroupID.y * gl_NumWorkGroups.x + gl_WorkGroupID.x;
+	if (block >= uint(blocks)) {
+		return;
+	}
+`
+
+func kernel(parts ...string) string {
+	return strings.Join(parts, "\n")
+}
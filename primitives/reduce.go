@@ -0,0 +1,99 @@
+package primitives
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// reduceGroups Work group limit of reduce pass, larger inputs are strided
+const reduceGroups = 1024
+
+const reduceKernel = `
+layout(std430, binding = 0) readonly buffer primInput {
+	PRIM_T inputValues[];
+};
+layout(std430, binding = 1) writeonly buffer primOutput {
+	PRIM_T outputValues[];
+};
+uniform int n;
+shared PRIM_T partial[256];
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+	uint local = gl_LocalInvocationID.x;
+	uint stride = gl_NumWorkGroups.x * 512u;
+	PRIM_T value = PRIM_IDENTITY;
+	for (uint i = gl_WorkGroupID.x * 512u + local; i < uint(n); i += stride) {
+		value = primOp(value, inputValues[i]);
+		if (i + 256u < uint(n)) {
+			value = primOp(value, inputValues[i + 256u]);
+		}
+	}
+	partial[local] = value;
+	barrier();
+	for (uint s = 128u; s > 0u; s >>= 1u) {
+		if (local < s) {
+			partial[local] = primOp(partial[local], partial[local + s]);
+		}
+		barrier();
+	}
+	if (local == 0u) {
+		outputValues[gl_WorkGroupID.x] = partial[0];
+	}
+}
+`
+
+// ReduceTo Combining n elements of input with op into first element of output
+func ReduceTo[T Element](p *Primitives, input *gc.GpuBuffer, n int, op Operator, output *gc.GpuBuffer) error {
+	if err := checkSize("Reduce", n, input); err != nil {
+		return err
+	}
+	if err := checkSize("Reduce output", 1, output); err != nil {
+		return err
+	}
+	return p.c.DoErr(func() error {
+		program, err := p.program(kernel(header(glslType[T](), op), reduceKernel))
+		if err != nil {
+			return err
+		}
+		p.c.UseProgram(program)
+		source, count := input, n
+		for level := 0; ; level++ {
+			groups := (count + blockSize - 1) / blockSize
+			if groups > reduceGroups {
+				groups = reduceGroups
+			}
+			destination := output
+			if groups > 1 {
+				destination = p.buffer([]string{"reduceA", "reduceB"}[level%2], groups*4)
+			}
+			source.SetBinding(0)
+			destination.SetBinding(1)
+			p.c.SetInt("n", count)
+			//Single group still runs for empty input and writes identity
+			if groups == 0 {
+				groups = 1
+			}
+			p.c.Realize(groups, 1, 1)
+			p.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+			if groups == 1 {
+				return nil
+			}
+			source, count = destination, groups
+		}
+	})
+}
+
+// Reduce Combining n elements of input with op, e.g. Sum, Min or Max
+func Reduce[T Element](p *Primitives, input *gc.GpuBuffer, n int, op Operator) (T, error) {
+	result := make([]T, 1)
+	err := p.c.DoErr(func() error {
+		output := p.buffer("reduceResult", 4)
+		if err := ReduceTo[T](p, input, n, op, output); err != nil {
+			return err
+		}
+		p.c.MemoryBarrier(gl.BUFFER_UPDATE_BARRIER_BIT)
+		gc.BufferReadInto(output, result, 0)
+		return nil
+	})
+	return result[0], err
+}
@@ -0,0 +1,81 @@
+package primitives
+
+import (
+	"errors"
+	"math"
+)
+
+// CPUOperator CPU version of Sum, Min or Max for reference results
+func CPUOperator[T Element](op Operator) (combine func(a, b T) T, identity T, err error) {
+	switch op.Name {
+	case Sum.Name:
+		return func(a, b T) T { return a + b }, 0, nil
+	case Min.Name:
+		switch any(identity).(type) {
+		case int32:
+			identity = any(int32(math.MaxInt32)).(T)
+		case uint32:
+			identity = any(uint32(math.MaxUint32)).(T)
+		default:
+			identity = any(float32(math.Inf(1))).(T)
+		}
+		return func(a, b T) T {
+			if b < a {
+				return b
+			}
+			return a
+		}, identity, nil
+	case Max.Name:
+		switch any(identity).(type) {
+		case int32:
+			identity = any(int32(math.MinInt32)).(T)
+		case uint32:
+			identity = 0
+		default:
+			identity = any(float32(math.Inf(-1))).(T)
+		}
+		return func(a, b T) T {
+			if b > a {
+				return b
+			}
+			return a
+		}, identity, nil
+	}
+	return nil, identity, errors.New("primitives: no CPU version of operator " + op.Name)
+}
+
+// ReduceCPU Reference of Reduce
+func ReduceCPU[T Element](data []T, combine func(a, b T) T, identity T) T {
+	result := identity
+	for _, v := range data {
+		result = combine(result, v)
+	}
+	return result
+}
+
+// ScanCPU Reference of Scan
+func ScanCPU[T Element](data []T, combine func(a, b T) T, identity T, exclusive bool) []T {
+	result := make([]T, len(data))
+	prefix := identity
+	for i, v := range data {
+		if exclusive {
+			result[i] = prefix
+			prefix = combine(prefix, v)
+		} else {
+			prefix = combine(prefix, v)
+			result[i] = prefix
+		}
+	}
+	return result
+}
+
+// CompactCPU Reference of Compact
+func CompactCPU[T Element](data []T, keep func(x T) bool) []T {
+	result := make([]T, 0, len(data))
+	for _, v := range data {
+		if keep(v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package primitives
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"strconv"
+)
+
+const scanKernel = `
+layout(std430, binding = 0) readonly buffer primInput {
+	PRIM_T inputValues[];
+};
+layout(std430, binding = 1) writeonly buffer primOutput {
+	PRIM_T outputValues[];
+};
+layout(std430, binding = 2) writeonly buffer primSums {
+	PRIM_T blockSums[];
+};
+uniform int n;
+uniform int blocks;
+uniform int exclusive;
+shared PRIM_T partial[256];
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+` + blockIndex + `
+	uint local = gl_LocalInvocationID.x;
+	uint i0 = block * 512u + 2u * local;
+	PRIM_T a = i0 < uint(n) ? inputValues[i0] : PRIM_IDENTITY;
+	PRIM_T b = i0 + 1u < uint(n) ? inputValues[i0 + 1u] : PRIM_IDENTITY;
+	partial[local] = primOp(a, b);
+	barrier();
+	for (uint offset = 1u; offset < 256u; offset <<= 1u) {
+		PRIM_T previous = local >= offset ? partial[local - offset] : PRIM_IDENTITY;
+		barrier();
+		partial[local] = primOp(previous, partial[local]);
+		barrier();
+	}
+	PRIM_T prefix = local > 0u ? partial[local - 1u] : PRIM_IDENTITY;
+	PRIM_T out0 = primOp(prefix, a);
+	PRIM_T out1 = primOp(out0, b);
+	if (exclusive != 0) {
+		out1 = out0;
+		out0 = prefix;
+	}
+	if (i0 < uint(n)) {
+		outputValues[i0] = out0;
+	}
+	if (i0 + 1u < uint(n)) {
+		outputValues[i0 + 1u] = out1;
+	}
+	if (local == 255u) {
+		blockSums[block] = partial[255];
+	}
+}
+`
+
+const scanAddKernel = `
+layout(std430, binding = 1) buffer primOutput {
+	PRIM_T outputValues[];
+};
+layout(std430, binding = 2) readonly buffer primSums {
+	PRIM_T blockSums[];
+};
+uniform int n;
+uniform int blocks;
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+` + blockIndex + `
+	if (block == 0u) {
+		return;
+	}
+	PRIM_T offset = blockSums[block - 1u];
+	uint i0 = block * 512u + 2u * gl_LocalInvocationID.x;
+	if (i0 < uint(n)) {
+		outputValues[i0] = primOp(offset, outputValues[i0]);
+	}
+	if (i0 + 1u < uint(n)) {
+		outputValues[i0 + 1u] = primOp(offset, outputValues[i0 + 1u]);
+	}
+}
+`
+
+// Scan Prefix combination of n elements of input into output with op
+// Exclusive scan starts from identity and leaves out own element
+func Scan[T Element](p *Primitives, input, output *gc.GpuBuffer, n int, op Operator, exclusive bool) error {
+	if err := checkSize("Scan", n, input, output); err != nil {
+		return err
+	}
+	return p.c.DoErr(func() error {
+		return p.scan(header(glslType[T](), op), input, output, n, exclusive, 0)
+	})
+}
+
+// scan Scanning blocks, then scanning block totals recursively and adding them to following blocks
+func (p *Primitives) scan(header string, input, output *gc.GpuBuffer, n int, exclusive bool, level int) error {
+	if n == 0 {
+		return nil
+	}
+	blocks := (n + blockSize - 1) / blockSize
+	program, err := p.program(kernel(header, scanKernel))
+	if err != nil {
+		return err
+	}
+	sums := p.buffer("scanSums"+strconv.Itoa(level), blocks*4)
+	p.c.UseProgram(program)
+	input.SetBinding(0)
+	output.SetBinding(1)
+	sums.SetBinding(2)
+	p.c.SetInt("n", n)
+	flag := 0
+	if exclusive {
+		flag = 1
+	}
+	p.c.SetInt("exclusive", flag)
+	p.dispatch(blocks)
+	if blocks == 1 {
+		return nil
+	}
+	scanned := p.buffer("scanTotals"+strconv.Itoa(level), blocks*4)
+	if err = p.scan(header, sums, scanned, blocks, false, level+1); err != nil {
+		return err
+	}
+	program, err = p.program(kernel(header, scanAddKernel))
+	if err != nil {
+		return err
+	}
+	p.c.UseProgram(program)
+	output.SetBinding(1)
+	scanned.SetBinding(2)
+	p.c.SetInt("n", n)
+	p.dispatch(blocks)
+	return nil
+}
@@ -0,0 +1,225 @@
+package primitives
+
+import (
+	gc "github.com/eszdman/gocompute"
+)
+
+// radixBits Key bits sorted per pass, 32 bit keys take eight passes
+const radixBits = 4
+const radixDigits = 1 << radixBits
+
+// sortCommon Per block digit ranks, packed as 16 bit counters, two digits per uint
+const sortCommon = `
+layout(std430, binding = 0) readonly buffer sortKeysIn {
+	uint keysIn[];
+};
+layout(std430, binding = 4) buffer sortCounts {
+	uint counts[];
+};
+uniform int n;
+uniform int blocks;
+uniform int shift;
+shared uvec4 lowCounts[256];
+shared uvec4 highCounts[256];
+
+uint digitCount(uvec4 low, uvec4 high, uint digit) {
+	uint word = digit < 8u ? low[(digit >> 1u) & 3u] : high[(digit >> 1u) & 3u];
+	return (word >> (16u * (digit & 1u))) & 0xFFFFu;
+}
+
+// rankDigits Inclusive count of equal digits up to every invocation of block
+void rankDigits(uint digit) {
+	uint local = gl_LocalInvocationID.x;
+	uvec4 low = uvec4(0u);
+	uvec4 high = uvec4(0u);
+	if (digit < 8u) {
+		low[(digit >> 1u) & 3u] = 1u << (16u * (digit & 1u));
+	} else if (digit < 16u) {
+		high[(digit >> 1u) & 3u] = 1u << (16u * (digit & 1u));
+	}
+	lowCounts[local] = low;
+	highCounts[local] = high;
+	barrier();
+	for (uint offset = 1u; offset < 256u; offset <<= 1u) {
+		uvec4 previousLow = local >= offset ? lowCounts[local - offset] : uvec4(0u);
+		uvec4 previousHigh = local >= offset ? highCounts[local - offset] : uvec4(0u);
+		barrier();
+		lowCounts[local] += previousLow;
+		highCounts[local] += previousHigh;
+		barrier();
+	}
+}
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+`
+
+const sortHistogramKernel = sortCommon + `
+void main() {
+` + blockIndex + `
+	uint i = block * 256u + gl_LocalInvocationID.x;
+	uint digit = i < uint(n) ? (keysIn[i] >> uint(shift)) & 15u : 16u;
+	rankDigits(digit);
+	uint local = gl_LocalInvocationID.x;
+	if (local < 16u) {
+		counts[local * uint(blocks) + block] = digitCount(lowCounts[255], highCounts[255], local);
+	}
+}
+`
+
+const sortScatterKernel = sortCommon + `
+layout(std430, binding = 1) writeonly buffer sortKeysOut {
+	uint keysOut[];
+};
+#ifdef SORT_VALUES
+layout(std430, binding = 2) readonly buffer sortValuesIn {
+	uint valuesIn[];
+};
+layout(std430, binding = 3) writeonly buffer sortValuesOut {
+	uint valuesOut[];
+};
+#endif
+void main() {
+` + blockIndex + `
+	uint local = gl_LocalInvocationID.x;
+	uint i = block * 256u + local;
+	uint key = i < uint(n) ? keysIn[i] : 0u;
+	uint digit = i < uint(n) ? (key >> uint(shift)) & 15u : 16u;
+	rankDigits(digit);
+	if (i < uint(n)) {
+		uint position = counts[digit * uint(blocks) + block] + digitCount(lowCounts[local], highCounts[local], digit) - 1u;
+		keysOut[position] = key;
+#ifdef SORT_VALUES
+		valuesOut[position] = valuesIn[i];
+#endif
+	}
+}
+`
+
+// sortFloatKernel Mapping float bits to unsigned order and back
+const sortFloatKernel = `
+layout(std430, binding = 0) buffer sortKeys {
+	uint keys[];
+};
+uniform int n;
+uniform int blocks;
+uniform int invert;
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+` + blockIndex + `
+	uint i = block * 256u + gl_LocalInvocationID.x;
+	if (i < uint(n)) {
+		uint bits = keys[i];
+		if (invert == 0) {
+			keys[i] = bits ^ ((bits >> 31u) != 0u ? 0xFFFFFFFFu : 0x80000000u);
+		} else {
+			keys[i] = bits ^ ((bits >> 31u) != 0u ? 0x80000000u : 0xFFFFFFFFu);
+		}
+	}
+}
+`
+
+// SortUint32 Sorting n keys of buffer in place
+func SortUint32(p *Primitives, keys *gc.GpuBuffer, n int) error {
+	return p.sort(keys, nil, n, false)
+}
+
+// SortFloat32 Sorting n keys of buffer in place, negative zero goes before zero and NaN by sign to ends
+func SortFloat32(p *Primitives, keys *gc.GpuBuffer, n int) error {
+	return p.sort(keys, nil, n, true)
+}
+
+// SortByKey Sorting n uint32 keys in place and moving 32 bit values with them, equal keys keep order
+func SortByKey(p *Primitives, keys, values *gc.GpuBuffer, n int) error {
+	return p.sort(keys, values, n, false)
+}
+
+// SortByFloat32Key Same as SortByKey for float32 keys
+func SortByFloat32Key(p *Primitives, keys, values *gc.GpuBuffer, n int) error {
+	return p.sort(keys, values, n, true)
+}
+
+// sort Least significant digit radix sort, even number of passes leaves result in caller buffers
+func (p *Primitives) sort(keys, values *gc.GpuBuffer, n int, float bool) error {
+	buffers := []*gc.GpuBuffer{keys}
+	if values != nil {
+		buffers = append(buffers, values)
+	}
+	if err := checkSize("Sort", n, buffers...); err != nil {
+		return err
+	}
+	if n < 2 {
+		return nil
+	}
+	return p.c.DoErr(func() error {
+		valuesDefine := ""
+		if values != nil {
+			valuesDefine = "#define SORT_VALUES\n"
+		}
+		histogram, err := p.program(sortHistogramKernel)
+		if err != nil {
+			return err
+		}
+		scatter, err := p.program(kernel(valuesDefine, sortScatterKernel))
+		if err != nil {
+			return err
+		}
+		blocks := (n + groupSize - 1) / groupSize
+		counts := p.buffer("sortCounts", radixDigits*blocks*4)
+		offsets := p.buffer("sortOffsets", radixDigits*blocks*4)
+		keysIn, keysOut := keys, p.buffer("sortKeys", n*4)
+		valuesIn, valuesOut := values, values
+		if values != nil {
+			valuesOut = p.buffer("sortValues", n*4)
+		}
+		if float {
+			if err = p.floatOrder(keys, n, blocks, false); err != nil {
+				return err
+			}
+		}
+		for shift := 0; shift < 32; shift += radixBits {
+			p.c.UseProgram(histogram)
+			keysIn.SetBinding(0)
+			counts.SetBinding(4)
+			p.c.SetInt("n", n)
+			p.c.SetInt("shift", shift)
+			p.dispatch(blocks)
+			//Digit major order turns exclusive scan into global output positions
+			if err = p.scan(header("uint", Sum), counts, offsets, radixDigits*blocks, true, 0); err != nil {
+				return err
+			}
+			p.c.UseProgram(scatter)
+			keysIn.SetBinding(0)
+			keysOut.SetBinding(1)
+			if values != nil {
+				valuesIn.SetBinding(2)
+				valuesOut.SetBinding(3)
+			}
+			offsets.SetBinding(4)
+			p.c.SetInt("n", n)
+			p.c.SetInt("shift", shift)
+			p.dispatch(blocks)
+			keysIn, keysOut = keysOut, keysIn
+			valuesIn, valuesOut = valuesOut, valuesIn
+		}
+		if float {
+			return p.floatOrder(keys, n, blocks, true)
+		}
+		return nil
+	})
+}
+
+func (p *Primitives) floatOrder(keys *gc.GpuBuffer, n, blocks int, inverse bool) error {
+	program, err := p.program(sortFloatKernel)
+	if err != nil {
+		return err
+	}
+	p.c.UseProgram(program)
+	keys.SetBinding(0)
+	p.c.SetInt("n", n)
+	flag := 0
+	if inverse {
+		flag = 1
+	}
+	p.c.SetInt("invert", flag)
+	p.dispatch(blocks)
+	return nil
+}
@@ -0,0 +1,200 @@
+package gocompute
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+const programCacheExt = ".glbin"
+
+// ProgramCache On-disk cache of linked program binaries
+// Entries are keyed by preprocessed source, defines and driver, least recently used entries are evicted above size limit
+type ProgramCache struct {
+	dir      string
+	maxBytes int64
+	mutex    sync.Mutex
+}
+
+// NewProgramCache Creating cache in dir, maxBytes <= 0 disables size limit
+func NewProgramCache(dir string, maxBytes int64) (*ProgramCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &ProgramCache{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// SetProgramCache Using cache for following LoadProgram calls, nil disables caching
+func (c *Computing) SetProgramCache(cache *ProgramCache) {
+	c.run(func() {
+		c.programCache = cache
+	})
+}
+
+// cacheKey Hash of preprocessed source, defines and driver
+func (c *Computing) cacheKey(programText string) string {
+	names := make([]string, 0, len(c.defineMap))
+	for name := range c.defineMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	hash.Write([]byte(c.deviceInfo().driver() + "\x00" + programText + "\x00"))
+	for _, name := range names {
+		hash.Write([]byte(name + "=" + c.defineMap[name] + "\x00"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (p *ProgramCache) path(key string) string {
+	return filepath.Join(p.dir, key+programCacheExt)
+}
+
+// load Reading binary format and data of key
+func (p *ProgramCache) load(key string) (uint32, []byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	data, err := os.ReadFile(p.path(key))
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) <= 4 {
+		os.Remove(p.path(key))
+		return 0, nil, errors.New("ProgramCache: truncated entry " + key)
+	}
+	//Touch entry for least recently used eviction
+	now := time.Now()
+	os.Chtimes(p.path(key), now, now)
+	return binary.LittleEndian.Uint32(data), data[4:], nil
+}
+
+// store Writing entry atomically and evicting old entries above limit
+func (p *ProgramCache) store(key string, format uint32, data []byte) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	entry := binary.LittleEndian.AppendUint32(make([]byte, 0, len(data)+4), format)
+	entry = append(entry, data...)
+	tmp, err := os.CreateTemp(p.dir, key+"*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(entry)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return p.evict()
+}
+
+func (p *ProgramCache) remove(key string) {
+	p.mutex.Lock()
+	os.Remove(p.path(key))
+	p.mutex.Unlock()
+}
+
+func (p *ProgramCache) evict() error {
+	if p.maxBytes <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+	type cached struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	files := make([]cached, 0, len(entries))
+	total := int64(0)
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), programCacheExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cached{filepath.Join(p.dir, entry.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		if total <= p.maxBytes {
+			break
+		}
+		if os.Remove(f.path) == nil {
+			total -= f.size
+		}
+	}
+	return nil
+}
+
+// Clear Removing every cached binary
+func (p *ProgramCache) Clear() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), programCacheExt) {
+			os.Remove(filepath.Join(p.dir, entry.Name()))
+		}
+	}
+	return nil
+}
+
+// loadCachedProgram Creating program from cached binary, zero when missing or rejected by driver
+func (c *Computing) loadCachedProgram(key string) uint32 {
+	format, data, err := c.programCache.load(key)
+	if err != nil {
+		return 0
+	}
+	program := gl.CreateProgram()
+	gl.ProgramBinary(program, format, unsafe.Pointer(&data[0]), int32(len(data)))
+	linkStatus := int32(0)
+	gl.GetProgramiv(program, gl.LINK_STATUS, &linkStatus)
+	if linkStatus == gl.FALSE {
+		//Driver update or corrupted entry, fall back to compilation
+		gl.DeleteProgram(program)
+		gl.GetError()
+		c.programCache.remove(key)
+		return 0
+	}
+	return program
+}
+
+// storeCachedProgram Saving binary of linked program
+func (c *Computing) storeCachedProgram(key string, program uint32) {
+	length := int32(0)
+	gl.GetProgramiv(program, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length <= 0 {
+		return
+	}
+	data := make([]byte, length)
+	format := uint32(0)
+	gl.GetProgramBinary(program, length, &length, &format, unsafe.Pointer(&data[0]))
+	CheckErr("GetProgramBinary")
+	if length > 0 {
+		c.programCache.store(key, format, data[:length])
+	}
+}
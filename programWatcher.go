@@ -0,0 +1,244 @@
+package gocompute
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgramWatcher Reloading program from disk when its file or any #include dependency changes
+// Includes are resolved relative to file including them and then to include dirs, nested includes are watched too
+type ProgramWatcher struct {
+	c           *Computing
+	id          int
+	path        string
+	includeDirs []string
+	mutex       sync.Mutex
+	modTimes    map[string]time.Time
+	stop        chan struct{}
+	// OnReload Called after every reload attempt with nil or compile error
+	OnReload func(err error)
+}
+
+// WatchProgram Loading program from file and returning watcher for it
+func (c *Computing) WatchProgram(path string, includeDirs ...string) (*ProgramWatcher, error) {
+	w := &ProgramWatcher{c: c, path: path, includeDirs: includeDirs}
+	text, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	c.run(func() {
+		count := c.programCounter
+		c.computeGroups[count] = &computeGroup{1, 1, 1}
+		if tuned := c.tunedDefines(text); tuned != nil {
+			defer c.withDefines(tuned)()
+		}
+		var program uint32
+		program, err = c.buildProgram(c.preProcess(text, c.computeGroups[count], w.include))
+		if err != nil {
+			delete(c.computeGroups, count)
+			return
+		}
+		c.programs[count] = program
+		c.programCounter++
+		w.id = count
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// ID Stable program id for UseProgram
+func (w *ProgramWatcher) ID() int {
+	return w.id
+}
+
+// Files Program file and resolved include files
+func (w *ProgramWatcher) Files() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	files := make([]string, 0, len(w.modTimes))
+	for file := range w.modTimes {
+		files = append(files, file)
+	}
+	return files
+}
+
+// resolve Finding include file by name written in #include line of file in dir
+func (w *ProgramWatcher) resolve(dir, name string) string {
+	name = strings.Trim(name, "\"<>")
+	dirs := append([]string{dir}, w.includeDirs...)
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// includeName File name written in #include line, empty for other lines
+func includeName(line string) string {
+	if !strings.Contains(line, "#include") {
+		return ""
+	}
+	split := strings.Split(line, " ")
+	return split[len(split)-1]
+}
+
+// include Include loader reading files from disk, names are resolved relative to program file
+func (w *ProgramWatcher) include(name string) string {
+	path := w.resolve(filepath.Dir(w.path), name)
+	if path == "" {
+		println("include:", name, "not found")
+		return ""
+	}
+	return w.expand(path, make(map[string]bool))
+}
+
+// expand Text of include file with nested includes resolved relative to file including them
+// active holds files being expanded, including one of them again would never end
+func (w *ProgramWatcher) expand(path string, active map[string]bool) string {
+	if active[path] {
+		println("include:", path, "includes itself")
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		println("include:", path, "not readable")
+		return ""
+	}
+	active[path] = true
+	defer delete(active, path)
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		name := includeName(line)
+		if name == "" {
+			continue
+		}
+		dependency := w.resolve(filepath.Dir(path), name)
+		if dependency == "" {
+			println("include:", name, "not found")
+			lines[i] = ""
+			continue
+		}
+		lines[i] = w.expand(dependency, active)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// load Reading program text and recording modification times of it and its nested dependencies
+func (w *ProgramWatcher) load() (string, error) {
+	modTimes := make(map[string]time.Time)
+	var text string
+	var visit func(path string) error
+	visit = func(path string) error {
+		if _, ok := modTimes[path]; ok {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		modTimes[path] = info.ModTime()
+		if path == w.path {
+			text = string(data)
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			name := includeName(scanner.Text())
+			if name == "" {
+				continue
+			}
+			if dependency := w.resolve(filepath.Dir(path), name); dependency != "" {
+				if err = visit(dependency); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit(w.path); err != nil {
+		return "", err
+	}
+	w.mutex.Lock()
+	w.modTimes = modTimes
+	w.mutex.Unlock()
+	return text, nil
+}
+
+// changed Any watched file was modified or removed since last load
+func (w *ProgramWatcher) changed() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for path, modTime := range w.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// Poll Checking files once and recompiling on change, must be called from GL thread without own Computing thread
+// Returns true when reload was attempted
+func (w *ProgramWatcher) Poll() (bool, error) {
+	if !w.changed() {
+		return false, nil
+	}
+	text, err := w.load()
+	if err == nil {
+		err = w.c.replaceProgram(w.id, text, w.include)
+	}
+	if w.OnReload != nil {
+		w.OnReload(err)
+	}
+	return true, err
+}
+
+// Start Polling files every interval in background, requires Computing with own GL thread
+func (w *ProgramWatcher) Start(interval time.Duration) error {
+	if w.c.thread == nil {
+		return errors.New("ProgramWatcher: background polling requires Computing with own GL thread, call Poll instead")
+	}
+	w.mutex.Lock()
+	if w.stop != nil {
+		w.mutex.Unlock()
+		return errors.New("ProgramWatcher: already started")
+	}
+	stop := make(chan struct{})
+	w.stop = stop
+	w.mutex.Unlock()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.Poll()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop Ending background polling, program stays loaded
+func (w *ProgramWatcher) Stop() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
@@ -0,0 +1,159 @@
+package gocompute
+
+import (
+	"github.com/go-gl/gl/all-core/gl"
+	"log"
+	"runtime/debug"
+	"sort"
+	"strconv"
+)
+
+type ResourceKind int
+
+const (
+	ResourceProgram ResourceKind = iota
+	ResourceShader
+	ResourceBuffer
+	ResourceTexture
+	ResourceSampler
+)
+
+func (k ResourceKind) String() string {
+	switch k {
+	case ResourceProgram:
+		return "program"
+	case ResourceShader:
+		return "shader"
+	case ResourceBuffer:
+		return "buffer"
+	case ResourceTexture:
+		return "texture"
+	case ResourceSampler:
+		return "sampler"
+	}
+	return "ResourceKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Resource Live GL object created by Computing
+type Resource struct {
+	Kind ResourceKind
+	ID   uint32
+	// Size storage bytes of buffers and textures
+	Size int
+	// Stack creation stack trace, recorded only in debug mode
+	Stack string
+}
+
+type resource struct {
+	Resource
+	closed bool
+}
+
+type resourceKey struct {
+	kind ResourceKind
+	id   uint32
+}
+
+// SetDebug Recording creation stack traces of resources
+func (c *Computing) SetDebug(enabled bool) {
+	c.resourceMutex.Lock()
+	c.debug = enabled
+	c.resourceMutex.Unlock()
+}
+
+func (c *Computing) track(kind ResourceKind, id uint32) *resource {
+	r := &resource{Resource: Resource{Kind: kind, ID: id}}
+	c.resourceMutex.Lock()
+	if c.debug {
+		r.Stack = string(debug.Stack())
+	}
+	c.resources[resourceKey{kind, id}] = r
+	c.resourceMutex.Unlock()
+	return r
+}
+
+// untrack Forgetting resource, returns false when it was already freed
+func (c *Computing) untrack(r *resource) bool {
+	c.resourceMutex.Lock()
+	defer c.resourceMutex.Unlock()
+	if r.closed {
+		return false
+	}
+	r.closed = true
+	delete(c.resources, resourceKey{r.Kind, r.ID})
+	return true
+}
+
+func (c *Computing) setSize(r *resource, size int) {
+	c.resourceMutex.Lock()
+	r.Size = size
+	c.resourceMutex.Unlock()
+}
+
+func (c *Computing) isClosed(r *resource) bool {
+	c.resourceMutex.Lock()
+	defer c.resourceMutex.Unlock()
+	return r.closed
+}
+
+// warnLeak Finalizer body for objects garbage collected without Close
+// Object stays tracked, so it is still freed by Computing.Close
+func (c *Computing) warnLeak(r *resource) {
+	c.resourceMutex.Lock()
+	defer c.resourceMutex.Unlock()
+	if !r.closed {
+		log.Println("W", r.Kind.String(), "object with ID:", r.ID, "garbage collected without Close", r.Stack)
+	}
+}
+
+// LiveResources Report of objects not yet closed, ordered by kind and ID
+func (c *Computing) LiveResources() []Resource {
+	c.resourceMutex.Lock()
+	live := make([]Resource, 0, len(c.resources))
+	for _, r := range c.resources {
+		live = append(live, r.Resource)
+	}
+	c.resourceMutex.Unlock()
+	sort.Slice(live, func(i, j int) bool {
+		if live[i].Kind != live[j].Kind {
+			return live[i].Kind < live[j].Kind
+		}
+		return live[i].ID < live[j].ID
+	})
+	return live
+}
+
+// deleteResource Deleting GL object of resource, must run on GL thread
+func deleteResource(r *resource) {
+	id := r.ID
+	switch r.Kind {
+	case ResourceProgram:
+		gl.DeleteProgram(id)
+	case ResourceShader:
+		gl.DeleteShader(id)
+	case ResourceBuffer:
+		gl.DeleteBuffers(1, &id)
+	case ResourceTexture:
+		gl.DeleteTextures(1, &id)
+	case ResourceSampler:
+		gl.DeleteSamplers(1, &id)
+	}
+}
+
+// freeResources Deleting every tracked object, must run on GL thread
+func (c *Computing) freeResources() {
+	c.resourceMutex.Lock()
+	live := make([]*resource, 0, len(c.resources))
+	for key, r := range c.resources {
+		r.closed = true
+		live = append(live, r)
+		delete(c.resources, key)
+	}
+	c.resourceMutex.Unlock()
+	for _, r := range live {
+		deleteResource(r)
+	}
+	c.programs = make(map[int]uint32)
+	c.builtins = make(map[string]int)
+	CheckErr("freeResources")
+}
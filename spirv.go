@@ -0,0 +1,229 @@
+package gocompute
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+const spirvMagic = 0x07230203
+
+// SPIR-V opcodes and enums used for reflection
+const (
+	spvOpName              = 5
+	spvOpEntryPoint        = 15
+	spvOpExecutionMode     = 16
+	spvOpSpecConstantTrue  = 48
+	spvOpSpecConstantFalse = 49
+	spvOpSpecConstant      = 50
+	spvOpDecorate          = 71
+	spvDecorationSpecId    = 1
+	spvExecutionModeLocal  = 17
+	spvExecutionModelGL    = 5
+)
+
+// SPIRVInfo Reflection data of SPIR-V module
+type SPIRVInfo struct {
+	// EntryPoints compute entry point names
+	EntryPoints []string
+	// LocalSize local_size of entry points by name, when declared as literal
+	LocalSize map[string][3]int
+	// SpecConstants specialization constant ids by debug name, unnamed constants use "#id"
+	SpecConstants map[string]uint32
+}
+
+// SpecIDs Sorted specialization constant ids
+func (info *SPIRVInfo) SpecIDs() []uint32 {
+	ids := make([]uint32, 0, len(info.SpecConstants))
+	for _, id := range info.SpecConstants {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// SpecFloat Bit pattern of float specialization constant value
+func SpecFloat(v float32) uint32 {
+	return math.Float32bits(v)
+}
+
+// SpecInt Bit pattern of int specialization constant value
+func SpecInt(v int32) uint32 {
+	return uint32(v)
+}
+
+// SpecBool Value of bool specialization constant
+func SpecBool(v bool) uint32 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func spirvString(words []uint32) string {
+	raw := make([]byte, 0, len(words)*4)
+	for _, w := range words {
+		raw = binary.LittleEndian.AppendUint32(raw, w)
+	}
+	if end := strings.IndexByte(string(raw), 0); end >= 0 {
+		raw = raw[:end]
+	}
+	return string(raw)
+}
+
+// ReflectSPIRV Parsing entry points, local sizes and specialization constants of module
+func ReflectSPIRV(code []byte) (*SPIRVInfo, error) {
+	if len(code) < 20 || len(code)%4 != 0 {
+		return nil, errors.New("SPIR-V: module size " + strconv.Itoa(len(code)) + " is not a multiple of 4 or too small")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(code) != spirvMagic {
+		order = binary.BigEndian
+		if order.Uint32(code) != spirvMagic {
+			return nil, errors.New("SPIR-V: wrong magic number")
+		}
+	}
+	words := make([]uint32, len(code)/4)
+	for i := range words {
+		words[i] = order.Uint32(code[i*4:])
+	}
+	info := &SPIRVInfo{LocalSize: make(map[string][3]int), SpecConstants: make(map[string]uint32)}
+	names := make(map[uint32]string)
+	entryIDs := make(map[uint32]string)
+	specIDs := make(map[uint32]uint32)
+	specResults := make(map[uint32]bool)
+	localSizes := make(map[uint32][3]int)
+	for pos := 5; pos < len(words); {
+		count := int(words[pos] >> 16)
+		op := words[pos] & 0xFFFF
+		if count == 0 || pos+count > len(words) {
+			return nil, errors.New("SPIR-V: malformed instruction at word " + strconv.Itoa(pos))
+		}
+		args := words[pos+1 : pos+count]
+		switch {
+		case op == spvOpName && len(args) >= 2:
+			names[args[0]] = spirvString(args[1:])
+		case op == spvOpEntryPoint && len(args) >= 3 && args[0] == spvExecutionModelGL:
+			name := spirvString(args[2:])
+			info.EntryPoints = append(info.EntryPoints, name)
+			entryIDs[args[1]] = name
+		case op == spvOpExecutionMode && len(args) >= 5 && args[1] == spvExecutionModeLocal:
+			localSizes[args[0]] = [3]int{int(args[2]), int(args[3]), int(args[4])}
+		case op == spvOpDecorate && len(args) >= 3 && args[1] == spvDecorationSpecId:
+			specIDs[args[0]] = args[2]
+		case (op == spvOpSpecConstantTrue || op == spvOpSpecConstantFalse || op == spvOpSpecConstant) && len(args) >= 2:
+			specResults[args[1]] = true
+		}
+		pos += count
+	}
+	for id, name := range entryIDs {
+		if size, ok := localSizes[id]; ok {
+			info.LocalSize[name] = size
+		}
+	}
+	for result, specID := range specIDs {
+		if !specResults[result] {
+			continue
+		}
+		name := names[result]
+		if name == "" {
+			name = "#" + strconv.Itoa(int(specID))
+		}
+		info.SpecConstants[name] = specID
+	}
+	return info, nil
+}
+
+// LoadProgramSPIRV Loading compute program from SPIR-V module, ids are shared with LoadProgram
+// specializationConstants maps constant ids to value bit patterns, see SpecFloat, SpecInt and SpecBool
+func (c *Computing) LoadProgramSPIRV(code []byte, entryPoint string, specializationConstants map[uint32]uint32) (id int, err error) {
+	info, err := ReflectSPIRV(code)
+	if err != nil {
+		return 0, err
+	}
+	found := false
+	for _, name := range info.EntryPoints {
+		found = found || name == entryPoint
+	}
+	if !found {
+		return 0, errors.New("SPIR-V: compute entry point \"" + entryPoint + "\" not found, module has [" + strings.Join(info.EntryPoints, ", ") + "]")
+	}
+	known := make(map[uint32]bool)
+	for _, specID := range info.SpecConstants {
+		known[specID] = true
+	}
+	constantIDs := make([]uint32, 0, len(specializationConstants))
+	constantValues := make([]uint32, 0, len(specializationConstants))
+	for specID, value := range specializationConstants {
+		if !known[specID] {
+			available := make([]string, 0, len(info.SpecConstants))
+			for name, id := range info.SpecConstants {
+				available = append(available, name+"="+strconv.Itoa(int(id)))
+			}
+			sort.Strings(available)
+			return 0, errors.New("SPIR-V: specialization constant " + strconv.Itoa(int(specID)) + " not declared, module has [" + strings.Join(available, ", ") + "]")
+		}
+		constantIDs = append(constantIDs, specID)
+		constantValues = append(constantValues, value)
+	}
+	c.run(func() {
+		if err = c.deviceInfo().Require(FeatureSPIRV); err != nil {
+			err = errors.New("SPIR-V: " + err.Error())
+			return
+		}
+		shaderHandle := gl.CreateShader(gl.COMPUTE_SHADER)
+		if shaderHandle == 0 {
+			err = errors.New("error creating shader")
+			return
+		}
+		shader := c.track(ResourceShader, shaderHandle)
+		defer func() {
+			gl.DeleteShader(shaderHandle)
+			c.untrack(shader)
+		}()
+		gl.ShaderBinary(1, &shaderHandle, gl.SHADER_BINARY_FORMAT_SPIR_V, unsafe.Pointer(&code[0]), int32(len(code)))
+		var idPtr, valuePtr *uint32
+		if len(constantIDs) > 0 {
+			idPtr, valuePtr = &constantIDs[0], &constantValues[0]
+		}
+		gl.SpecializeShader(shaderHandle, gl.Str(entryPoint+"\x00"), uint32(len(constantIDs)), idPtr, valuePtr)
+		compileStatus := int32(0)
+		gl.GetShaderiv(shaderHandle, gl.COMPILE_STATUS, &compileStatus)
+		if compileStatus == gl.FALSE {
+			outLen := int32(0)
+			var infoLog [1024]byte
+			gl.GetShaderInfoLog(shaderHandle, 1024, &outLen, &(infoLog[0]))
+			err = errors.New("Error specializing SPIR-V shader \"" + entryPoint + "\": " + string(infoLog[:outLen]))
+			return
+		}
+		program := gl.CreateProgram()
+		gl.AttachShader(program, shaderHandle)
+		gl.LinkProgram(program)
+		gl.DetachShader(program, shaderHandle)
+		linkStatus := int32(0)
+		gl.GetProgramiv(program, gl.LINK_STATUS, &linkStatus)
+		if linkStatus == gl.FALSE {
+			outLen := int32(0)
+			var infoLog [1024]byte
+			gl.GetProgramInfoLog(program, 1024, &outLen, &(infoLog[0]))
+			gl.DeleteProgram(program)
+			err = errors.New("Error linking SPIR-V program: " + string(infoLog[:outLen]))
+			return
+		}
+		c.track(ResourceProgram, program)
+		id = c.programCounter
+		group := &computeGroup{1, 1, 1}
+		if size, ok := info.LocalSize[entryPoint]; ok {
+			group = &computeGroup{size[0], size[1], size[2]}
+		}
+		c.computeGroups[id] = group
+		c.programs[id] = program
+		c.programCounter++
+	})
+	return id, err
+}
@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/gl/all-core/gl"
+	"math"
+)
+
+// maxSharedBins Bins of all channels kept in shared memory
+const maxSharedBins = 4096
+
+const histogramKernel = `
+layout(std430, binding = 1) buffer statsBins {
+	uint bins[];
+};
+uniform int count;
+uniform float low;
+uniform float high;
+shared uint localBins[BINS * CHANNELS];
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+void main() {
+	for (uint i = gl_LocalInvocationID.x; i < uint(BINS * CHANNELS); i += 256u) {
+		localBins[i] = 0u;
+	}
+	barrier();
+	float scale = float(BINS) / (high - low);
+	int stride = int(gl_NumWorkGroups.x) * 256;
+	for (int i = int(gl_WorkGroupID.x * 256u + gl_LocalInvocationID.x); i < count; i += stride) {
+		vec4 texel = sampleTexel(i);
+		for (int c = 0; c < CHANNELS; c++) {
+			if (!isnan(texel[c])) {
+				int bin = int(clamp((texel[c] - low) * scale, 0.0, float(BINS - 1)));
+				atomicAdd(localBins[c * BINS + bin], 1u);
+			}
+		}
+	}
+	barrier();
+	for (uint i = gl_LocalInvocationID.x; i < uint(BINS * CHANNELS); i += 256u) {
+		if (localBins[i] != 0u) {
+			atomicAdd(bins[i], localBins[i]);
+		}
+	}
+}
+`
+
+// Histogram Per channel counts of equal width bins over [Low, High)
+// Values outside range are counted in edge bins, NaN is skipped
+type Histogram struct {
+	Low, High float32
+	Bins      [][]uint32
+}
+
+// Histogram Counting values of every channel into bins over [low, high)
+func (s *Stats) Histogram(source Source, bins int, low, high float32) (*Histogram, error) {
+	if bins < 1 || bins*source.channels > maxSharedBins {
+		return nil, fmt.Errorf("stats: %d bins of %d channels, at most %d in total", bins, source.channels, maxSharedBins)
+	}
+	if !(high > low) {
+		return nil, errors.New("stats: histogram range is empty")
+	}
+	header, err := source.header()
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]uint32, bins*source.channels)
+	err = s.c.DoErr(func() error {
+		text := fmt.Sprintf("#define BINS %d\n#define CHANNELS %d\n", bins, source.channels) + header + histogramKernel
+		program, err := s.program(text)
+		if err != nil {
+			return err
+		}
+		output := s.buffer("histogram", len(counts)*4)
+		gc.BufferPartialLoad(output, counts, 0)
+		s.c.UseProgram(program)
+		if err = source.bind(s.c); err != nil {
+			return err
+		}
+		output.SetBinding(1)
+		s.c.SetInt("count", source.count)
+		s.c.SetFloat32("low", low)
+		s.c.SetFloat32("high", high)
+		s.c.Realize(groups(source.count), 1, 1)
+		s.c.MemoryBarrier(gl.BUFFER_UPDATE_BARRIER_BIT)
+		gc.BufferReadInto(output, counts, 0)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	h := &Histogram{Low: low, High: high, Bins: make([][]uint32, source.channels)}
+	for c := range h.Bins {
+		h.Bins[c] = counts[c*bins : (c+1)*bins]
+	}
+	return h, nil
+}
+
+// Total Counted values of channel
+func (h *Histogram) Total(channel int) uint64 {
+	total := uint64(0)
+	for _, v := range h.Bins[channel] {
+		total += uint64(v)
+	}
+	return total
+}
+
+// Percentile Value below which fraction p of channel values lies, interpolated inside bin
+func (h *Histogram) Percentile(channel int, p float64) float32 {
+	bins := h.Bins[channel]
+	total := h.Total(channel)
+	if total == 0 {
+		return float32(math.NaN())
+	}
+	p = math.Max(0, math.Min(1, p))
+	width := float64(h.High-h.Low) / float64(len(bins))
+	target := p * float64(total)
+	cumulative := 0.0
+	for i, v := range bins {
+		if v > 0 && cumulative+float64(v) >= target {
+			return float32(float64(h.Low) + width*(float64(i)+(target-cumulative)/float64(v)))
+		}
+		cumulative += float64(v)
+	}
+	return h.High
+}
+
+// Percentiles Percentiles of every channel estimated from histogram over value range of source
+func (s *Stats) Percentiles(source Source, bins int, ps ...float64) ([][]float32, error) {
+	moments, err := s.Moments(source)
+	if err != nil {
+		return nil, err
+	}
+	low, high := float32(math.Inf(1)), float32(math.Inf(-1))
+	for _, m := range moments {
+		if m.Count > 0 {
+			low = float32(math.Min(float64(low), m.Min))
+			high = float32(math.Max(float64(high), m.Max))
+		}
+	}
+	if low > high {
+		return nil, errors.New("stats: source has no values")
+	}
+	//Upper edge belongs to last bin
+	high = math.Nextafter32(high, float32(math.Inf(1)))
+	h, err := s.Histogram(source, bins, low, high)
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]float32, source.channels)
+	for c := range result {
+		result[c] = make([]float32, len(ps))
+		for i, p := range ps {
+			result[c][i] = h.Percentile(c, p)
+		}
+	}
+	return result, nil
+}
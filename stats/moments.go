@@ -0,0 +1,160 @@
+package stats
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/gl/all-core/gl"
+	"math"
+)
+
+// momentsCommon Parallel Welford state (count, mean, m2) with min and max, combined pairwise
+const momentsCommon = `
+layout(std430, binding = 2) buffer statsPartials {
+	vec4 partials[];
+};
+uniform int channel;
+uniform int partialCount;
+shared vec4 states[256];
+shared vec2 ranges[256];
+
+void combine(uint a, uint b) {
+	vec4 x = states[a];
+	vec4 y = states[b];
+	float n = x.x + y.x;
+	if (y.x == 0.0) {
+		return;
+	}
+	if (x.x == 0.0) {
+		states[a] = y;
+		ranges[a] = ranges[b];
+		return;
+	}
+	float delta = y.y - x.y;
+	states[a] = vec4(n, x.y + delta * y.x / n, x.z + y.z + delta * delta * x.x * y.x / n, 0.0);
+	ranges[a] = vec2(min(ranges[a].x, ranges[b].x), max(ranges[a].y, ranges[b].y));
+}
+
+void reduceStates(uint slot) {
+	uint local = gl_LocalInvocationID.x;
+	barrier();
+	for (uint s = 128u; s > 0u; s >>= 1u) {
+		if (local < s) {
+			combine(local, local + s);
+		}
+		barrier();
+	}
+	if (local == 0u) {
+		partials[2u * slot] = states[0];
+		partials[2u * slot + 1u] = vec4(ranges[0], 0.0, 0.0);
+	}
+}
+layout(local_size_x = 256, local_size_y = 1, local_size_z = 1) in;
+`
+
+const momentsKernel = momentsCommon + `
+uniform int count;
+void main() {
+	uint local = gl_LocalInvocationID.x;
+	vec4 state = vec4(0.0);
+	vec2 range = vec2(uintBitsToFloat(0x7F800000u), uintBitsToFloat(0xFF800000u));
+	int stride = int(gl_NumWorkGroups.x) * 256;
+	for (int i = int(gl_WorkGroupID.x * 256u + local); i < count; i += stride) {
+		float v = sampleTexel(i)[channel];
+		if (isnan(v)) {
+			continue;
+		}
+		state.x += 1.0;
+		float delta = v - state.y;
+		state.y += delta / state.x;
+		state.z += delta * (v - state.y);
+		range = vec2(min(range.x, v), max(range.y, v));
+	}
+	states[local] = state;
+	ranges[local] = range;
+	reduceStates(uint(channel * partialCount) + gl_WorkGroupID.x);
+}
+`
+
+// momentsCombineKernel Combining partials of channel into slot after all partials
+const momentsCombineKernel = momentsCommon + `
+uniform int channels;
+void main() {
+	uint local = gl_LocalInvocationID.x;
+	uint first = uint(channel * partialCount);
+	states[local] = vec4(0.0);
+	ranges[local] = vec2(uintBitsToFloat(0x7F800000u), uintBitsToFloat(0xFF800000u));
+	if (local < uint(partialCount)) {
+		states[local] = partials[2u * (first + local)];
+		ranges[local] = partials[2u * (first + local) + 1u].xy;
+	}
+	reduceStates(uint(channels * partialCount + channel));
+}
+`
+
+// Moments Statistics of one channel, Variance is population variance
+// Count is accumulated in float32 and exact up to 2^24 values
+type Moments struct {
+	Count    int
+	Min, Max float64
+	Mean     float64
+	Variance float64
+	StdDev   float64
+}
+
+// Moments Count, range, mean and variance of every channel, NaN is skipped
+func (s *Stats) Moments(source Source) ([]Moments, error) {
+	header, err := source.header()
+	if err != nil {
+		return nil, err
+	}
+	partialCount := groups(source.count)
+	results := make([]gc.Vec4, 2*source.channels)
+	err = s.c.DoErr(func() error {
+		first, err := s.program(header + momentsKernel)
+		if err != nil {
+			return err
+		}
+		combine, err := s.program(momentsCombineKernel)
+		if err != nil {
+			return err
+		}
+		partials := s.buffer("moments", (partialCount+1)*source.channels*2*16)
+		s.c.UseProgram(first)
+		if err = source.bind(s.c); err != nil {
+			return err
+		}
+		partials.SetBinding(2)
+		s.c.SetInt("count", source.count)
+		s.c.SetInt("partialCount", partialCount)
+		for c := 0; c < source.channels; c++ {
+			s.c.SetInt("channel", c)
+			s.c.Realize(partialCount, 1, 1)
+		}
+		s.c.MemoryBarrier(gl.SHADER_STORAGE_BARRIER_BIT)
+		s.c.UseProgram(combine)
+		s.c.SetInt("partialCount", partialCount)
+		s.c.SetInt("channels", source.channels)
+		for c := 0; c < source.channels; c++ {
+			s.c.SetInt("channel", c)
+			s.c.Realize(1, 1, 1)
+		}
+		s.c.MemoryBarrier(gl.BUFFER_UPDATE_BARRIER_BIT)
+		gc.BufferReadInto(partials, results, source.channels*partialCount*2*16)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	moments := make([]Moments, source.channels)
+	for c := range moments {
+		state, span := results[2*c], results[2*c+1]
+		m := Moments{Count: int(state.X), Mean: float64(state.Y), Min: float64(span.X), Max: float64(span.Y)}
+		if state.X > 0 {
+			m.Variance = float64(state.Z) / float64(state.X)
+			m.StdDev = math.Sqrt(m.Variance)
+		} else {
+			m.Mean = math.NaN()
+		}
+		moments[c] = m
+	}
+	return moments, nil
+}
@@ -0,0 +1,144 @@
+// Package stats Histograms, moments and percentiles of buffers and textures computed on GPU
+package stats
+
+import (
+	"errors"
+	"fmt"
+	gc "github.com/eszdman/gocompute"
+	"strings"
+)
+
+// maxGroups Work groups of first pass, partial results stay on GPU
+const maxGroups = 256
+
+// Source Float32 buffer with interleaved channels or 2D texture
+type Source struct {
+	buffer   *gc.GpuBuffer
+	texture  *gc.GpuTexture
+	count    int
+	channels int
+}
+
+// BufferSource count values per channel of float32 buffer with interleaved channels
+func BufferSource(buffer *gc.GpuBuffer, count, channels int) Source {
+	return Source{buffer: buffer, count: count, channels: channels}
+}
+
+// TextureSource Every texel of 2D texture of normalized or float format
+func TextureSource(texture *gc.GpuTexture) Source {
+	return Source{texture: texture, count: texture.SizeX * texture.SizeY, channels: texture.Channels()}
+}
+
+func (s Source) Channels() int {
+	return s.channels
+}
+
+// header Declaring vec4 sampleTexel(int i) for source
+func (s Source) header() (string, error) {
+	if s.channels < 1 || s.channels > 4 {
+		return "", errors.New("stats: source must have 1 to 4 channels")
+	}
+	if s.texture != nil {
+		format, err := s.texture.TextureFormat()
+		if err != nil {
+			return "", err
+		}
+		if !format.ImageLoadStore() || strings.HasSuffix(format.Layout, "i") {
+			return "", errors.New("stats: texture " + s.texture.Type().String() + " is not a float image format")
+		}
+		return `
+layout(` + format.Layout + `, binding = 0) readonly uniform image2D src;
+uniform int width;
+vec4 sampleTexel(int i) {
+	return imageLoad(src, ivec2(i % width, i / width));
+}
+`, nil
+	}
+	if s.buffer.ByteSize() < s.count*s.channels*4 {
+		return "", fmt.Errorf("stats: %d values of %d channels need %d bytes, buffer has %d", s.count, s.channels, s.count*s.channels*4, s.buffer.ByteSize())
+	}
+	return fmt.Sprintf(`
+layout(std430, binding = 0) readonly buffer statsInput {
+	float inputValues[];
+};
+vec4 sampleTexel(int i) {
+	vec4 texel = vec4(0.0);
+	for (int c = 0; c < %d; c++) {
+		texel[c] = inputValues[i * %d + c];
+	}
+	return texel;
+}
+`, s.channels, s.channels), nil
+}
+
+// bind Binding source and setting its uniforms on current program
+func (s Source) bind(c *gc.Computing) error {
+	if s.texture != nil {
+		if err := s.texture.SetBinding(0); err != nil {
+			return err
+		}
+		c.SetInt("width", s.texture.SizeX)
+		return nil
+	}
+	s.buffer.SetBinding(0)
+	return nil
+}
+
+// Stats Cached kernels and scratch buffers of one Computing
+// Operations use image unit 0 and storage bindings 0 to 2
+type Stats struct {
+	c        *gc.Computing
+	programs map[string]int
+	scratch  map[string]*gc.GpuBuffer
+}
+
+func New(c *gc.Computing) *Stats {
+	return &Stats{c: c, programs: make(map[string]int), scratch: make(map[string]*gc.GpuBuffer)}
+}
+
+func (s *Stats) program(text string) (int, error) {
+	if id, ok := s.programs[text]; ok {
+		return id, nil
+	}
+	id, err := s.c.LoadProgram(text)
+	if err != nil {
+		return 0, errors.New("stats: " + err.Error())
+	}
+	s.programs[text] = id
+	return id, nil
+}
+
+func (s *Stats) buffer(name string, bytes int) *gc.GpuBuffer {
+	b := s.scratch[name]
+	if b == nil {
+		b = s.c.NewBufferV(gc.BDynamicCopy, gc.BStorage)
+		s.scratch[name] = b
+	} else if b.ByteSize() >= bytes {
+		return b
+	}
+	gc.BufferAllocateBytes(b, bytes, 1)
+	return b
+}
+
+func groups(count int) int {
+	g := (count + 255) / 256
+	if g > maxGroups {
+		return maxGroups
+	}
+	if g < 1 {
+		return 1
+	}
+	return g
+}
+
+// Close Deleting kernels and scratch buffers
+func (s *Stats) Close() {
+	for _, id := range s.programs {
+		s.c.DeleteProgram(id)
+	}
+	for _, b := range s.scratch {
+		b.Close()
+	}
+	s.programs = make(map[string]int)
+	s.scratch = make(map[string]*gc.GpuBuffer)
+}
@@ -4,10 +4,13 @@ import (
 	"embed"
 	_ "embed"
 	gc "github.com/eszdman/gocompute"
+	"github.com/eszdman/gocompute/glfwcontext"
 	"github.com/go-gl/gl/all-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"log"
 	"math"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -35,6 +38,12 @@ var functionsTest string
 //go:embed resources/speedTest2.glsl
 var speedTest2 string
 
+//go:embed resources/appendTest.glsl
+var appendTest string
+
+//go:embed resources/tuneTest.glsl
+var tuneTest string
+
 //go:embed resources/include/*
 var includes embed.FS
 
@@ -163,7 +172,16 @@ func TextureExample2(compute *gc.Computing, program int) {
 	texture.SetBinding(0)
 	compute.Realize(2, 1, 1)
 	log.Println("D", texture.ReadFloat32())
-	log.Println("D", gc.TextureRead[float32](texture))
+	//Read into caller owned slice, no internal buffer involved
+	readPoints := make([]gc.Vec4, 2)
+	if err := gc.TextureReadInto(texture, readPoints); err != nil {
+		log.Println("E", err)
+	}
+	log.Println("D", readPoints)
+	//Too small destination is rejected instead of overflowing
+	if err := gc.TextureReadInto(texture, make([]float32, 7)); err == nil {
+		log.Println("E", "TextureReadInto accepted undersized slice")
+	}
 	texture.Close()
 }
 
@@ -256,10 +274,8 @@ func SpeedTest2(compute *gc.Computing, program int) {
 	buffer2 := compute.NewTexture(gc.FLOAT32, 1)
 	buffer2.Create2D(elementsCount, elementsCount2)
 	buffer.Create2D(elementsCount, elementsCount2)
-	// Init input-output slices before the loop to avoid memory leak
-	gc.InternalBuffer[float32](buffer) //Internal buffer is required for reading texture
-	//Buffer increase stability against garbage collector but memory consumption also
-	gc.InternalBuffer[float32](buffer2)
+	// Init readback slice before the loop to avoid allocation per iteration
+	read := make([]float32, elementsCount*elementsCount2)
 
 	for j := 1; j < 1000; j++ {
 		//Load data into buffer instead of allocation
@@ -269,8 +285,12 @@ func SpeedTest2(compute *gc.Computing, program int) {
 
 		buffer.Load2DFloat32(b)
 
-		if gc.TextureRead[float32](buffer)[elementsCount*elementsCount2-1] != float32(elementsCount*elementsCount2-1) {
-			log.Println("E", "Wrong buffer value:", gc.TextureRead[float32](buffer)[elementsCount*elementsCount2-1],
+		if err := buffer.ReadFloat32Into(read); err != nil {
+			log.Println("E", err)
+			break
+		}
+		if read[elementsCount*elementsCount2-1] != float32(elementsCount*elementsCount2-1) {
+			log.Println("E", "Wrong buffer value:", read[elementsCount*elementsCount2-1],
 				"instead of:", float32(elementsCount*elementsCount2-1))
 			break
 		}
@@ -289,6 +309,277 @@ func SpeedTest2(compute *gc.Computing, program int) {
 	buffer2.Close()
 }
 
+func GraphExample(compute *gc.Computing, program int) {
+	log.Println("D", "GraphExample started")
+	graph := compute.NewGraph()
+	input := graph.Buffer("input")
+	output := graph.Buffer("output")
+	//Transient buffers share storage when lifetimes don't overlap, t3 reuses t1
+	t1 := graph.TransientBuffer("t1", 9*4)
+	t2 := graph.TransientBuffer("t2", 9*4)
+	t3 := graph.TransientBuffer("t3", 9*4)
+	//Nodes may be declared in any order, dependencies come from inputs and outputs
+	graph.AddNode("last", program).Input(1, t3).Output(2, output).Dispatch(9, 1, 1)
+	graph.AddNode("first", program).Input(1, input).Output(2, t1).Dispatch(9, 1, 1)
+	graph.AddNode("third", program).Input(1, t2).Output(2, t3).Dispatch(9, 1, 1)
+	graph.AddNode("second", program).Input(1, t1).Output(2, t2).Dispatch(9, 1, 1)
+	names, barriers, err := graph.Barriers()
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	log.Println("D", names, barriers)
+	outputBuffer := compute.NewBuffer()
+	outputBuffer.AllocateFloat32(9)
+	graph.BindBuffer("output", outputBuffer)
+	//Same graph runs again with new input
+	for run := 0; run < 2; run++ {
+		target := make([]float32, 9)
+		for i := range target {
+			target[i] = float32(i * run)
+		}
+		inputBuffer := compute.NewBuffer()
+		inputBuffer.LoadFloat32(target)
+		graph.BindBuffer("input", inputBuffer)
+		if err = graph.Execute(); err != nil {
+			log.Println("E", err)
+		}
+		read := outputBuffer.ReadFloat32(9)
+		for i := range read {
+			if val := target[i] + float32(4*i); val != read[i] {
+				log.Println("E", "Wrong graph output", "ind:", i, "expected:", val, "got:", read[i])
+			}
+		}
+		inputBuffer.Close()
+	}
+	if t1.Buffer() != t3.Buffer() {
+		log.Println("E", "t3 doesn't reuse storage of t1")
+	}
+	graph.Close()
+	outputBuffer.Close()
+}
+func PingPongExample(compute *gc.Computing, program int) {
+	log.Println("D", "PingPongExample started")
+	buffer := compute.NewBuffer()
+	buffer2 := compute.NewBuffer()
+	target := []float32{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	buffer.LoadFloat32(target)
+	buffer2.AllocateFloat32(len(target))
+	//Program reads binding 1 and writes binding 2, objects swap every step
+	pingPong := compute.NewBufferPingPong(buffer, buffer2, len(target), program, 1, 2)
+	if err := pingPong.Step(5); err != nil {
+		log.Println("E", err)
+	}
+	read := pingPong.Current().ReadFloat32(len(target))
+	for i := range read {
+		if val := target[i] + float32(5*i); val != read[i] {
+			log.Println("E", "Wrong ping-pong output", "ind:", i, "expected:", val, "got:", read[i])
+		}
+	}
+	buffer.Close()
+	buffer2.Close()
+}
+func IndirectExample(compute *gc.Computing, program int) {
+	log.Println("D", "IndirectExample started")
+	buffer := compute.NewBuffer()
+	buffer2 := compute.NewBuffer()
+	target := []float32{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	buffer.LoadFloat32(target)
+	buffer2.LoadFloat32(make([]float32, len(target)))
+	//Count is usually written by previous kernel, e.g. stream compaction
+	count := compute.NewBuffer()
+	count.LoadInt32([]int32{7})
+	dispatch := compute.NewDispatchBuffer()
+	buffer.SetBinding(1)
+	buffer2.SetBinding(2)
+	if err := compute.RealizeCount(program, count, 0, dispatch); err != nil {
+		log.Println("E", err)
+	}
+	read := buffer2.ReadFloat32(len(target))
+	for i := range read {
+		val := target[i] + float32(i)
+		if i >= 7 {
+			val = 0
+		}
+		if val != read[i] {
+			log.Println("E", "Wrong indirect output", "ind:", i, "expected:", val, "got:", read[i])
+		}
+	}
+	//Count past end of buffer is rejected before kernel reads it
+	if err := compute.IndirectFromCount(count, 4, dispatch, 0, 1); err == nil {
+		log.Println("E", "count offset past buffer end accepted")
+	}
+	buffer.Close()
+	buffer2.Close()
+	count.Close()
+	dispatch.Close()
+}
+func AppendExample(compute *gc.Computing, program int) {
+	log.Println("D", "AppendExample started")
+	buffer := compute.NewBuffer()
+	target := []float32{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	buffer.LoadFloat32(target)
+	appendBuffer := gc.NewAppendBuffer[float32](compute, len(target))
+	compute.UseProgram(program)
+	buffer.SetBinding(1)
+	appendBuffer.SetBinding(2, 0)
+	compute.SetInt("capacity", appendBuffer.Cap())
+	//Counter can also be bound by name of atomic_uint
+	if err := appendBuffer.Counter().SetBindingName("outputCount"); err != nil {
+		log.Println("E", err)
+	}
+	compute.Realize(len(target), 1, 1)
+	//Order of appended elements is not defined
+	read := appendBuffer.Read()
+	sum := float32(0)
+	for _, v := range read {
+		sum += v
+	}
+	if len(read) != 5 || sum != 5+6+7+8+9 || appendBuffer.Overflowed() {
+		log.Println("E", "Wrong appended elements", read)
+	}
+	appendBuffer.Reset()
+	if appendBuffer.Len() != 0 {
+		log.Println("E", "Append buffer is not empty after reset")
+	}
+	buffer.Close()
+	appendBuffer.Close()
+}
+func ResourcesExample(compute *gc.Computing) {
+	log.Println("D", "ResourcesExample started")
+	//Record creation stack traces for leak reports
+	compute.SetDebug(true)
+	before := len(compute.LiveResources())
+	buffer := compute.NewBuffer()
+	buffer.AllocateFloat32(16)
+	texture := compute.NewTexture(gc.FLOAT32, 4)
+	texture.Create2D(4, 4)
+	for _, r := range compute.LiveResources() {
+		log.Println("D", r.Kind, r.ID, r.Size, "bytes")
+	}
+	if len(compute.LiveResources()) != before+2 {
+		log.Println("E", "buffer and texture are not tracked")
+	}
+	buffer.Close()
+	texture.Close()
+	if len(compute.LiveResources()) != before {
+		log.Println("E", "closed objects are still tracked")
+	}
+	compute.SetDebug(false)
+}
+
+func HostImageExample(compute *gc.Computing) {
+	log.Println("D", "HostImageExample started")
+	buffer := compute.NewBuffer()
+	buffer.LoadFloat32([]float32{1, 2, 3, 4, 5, 6})
+	if img, err := gc.BufferToHost(buffer, 3, 2, 1, 1, gc.FLOAT32); err != nil || gc.HostImageData[float32](img)[5] != 6 {
+		log.Println("E", "BufferToHost", err)
+	}
+	//Buffer smaller than image is rejected instead of read past its end
+	if _, err := gc.BufferToHost(buffer, 3, 2, 1, 2, gc.FLOAT32); err == nil {
+		log.Println("E", "short buffer was read as image")
+	}
+	buffer.Close()
+}
+
+func NPYBufferExample(compute *gc.Computing, dir string) {
+	log.Println("D", "NPYBufferExample started")
+	buffer := compute.NewBuffer()
+	//Byte sized allocation is saved as elements of whole storage
+	gc.BufferAllocateBytes(buffer, 12, 1)
+	gc.BufferPartialLoad(buffer, []float32{1, 2, 3}, 0)
+	path := filepath.Join(dir, "buffer.npy")
+	if err := gc.SaveNPY[float32](path, buffer); err != nil {
+		log.Println("E", err)
+	}
+	file, _ := os.Open(path)
+	if data, _, err := gc.ReadNPY[float32](file); err != nil || len(data) != 3 || data[2] != 3 {
+		log.Println("E", "SaveNPY", data, err)
+	}
+	file.Close()
+	gc.BufferAllocateBytes(buffer, 10, 1)
+	if err := gc.SaveNPY[float32](path, buffer); err == nil {
+		log.Println("E", "partial element was saved")
+	}
+	buffer.Close()
+}
+
+func HotReloadExample(compute *gc.Computing, dir string) {
+	log.Println("D", "HotReloadExample started")
+	path := filepath.Join(dir, "functionsTest.glsl")
+	os.WriteFile(path, []byte(functionsTest), 0644)
+	gaussian, _ := includes.ReadFile("resources/include/gaussian.glsl")
+	os.WriteFile(filepath.Join(dir, "gaussian.glsl"), gaussian, 0644)
+	watcher, err := compute.WatchProgram(path)
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	log.Println("D", "watching", watcher.Files())
+	program := watcher.ID()
+	//Broken include keeps previous program active under the same id
+	os.WriteFile(filepath.Join(dir, "gaussian.glsl"), []byte("float pdf(float d) { return d +; }"), 0644)
+	later := time.Now().Add(time.Second)
+	os.Chtimes(filepath.Join(dir, "gaussian.glsl"), later, later)
+	if reloaded, err := watcher.Poll(); !reloaded || err == nil {
+		log.Println("E", "broken include was not rejected")
+	}
+	FunctionsExample(compute, program)
+	//Valid change replaces program, id stays stable
+	os.WriteFile(filepath.Join(dir, "gaussian.glsl"), []byte("float pdf(float d) { return 1.0; }"), 0644)
+	later = later.Add(time.Second)
+	os.Chtimes(filepath.Join(dir, "gaussian.glsl"), later, later)
+	if _, err = watcher.Poll(); err != nil {
+		log.Println("E", err)
+	}
+	FunctionsExample(compute, program)
+	//Nested includes resolve relative to file including them and are watched too
+	os.MkdirAll(filepath.Join(dir, "lib"), 0755)
+	os.WriteFile(filepath.Join(dir, "lib", "scale.glsl"), []byte("float scale() { return 1.0; }"), 0644)
+	os.WriteFile(filepath.Join(dir, "lib", "pdf.glsl"), []byte("#include \"scale.glsl\"\nfloat pdf(float d) { return scale(); }"), 0644)
+	os.WriteFile(filepath.Join(dir, "gaussian.glsl"), []byte("#include \"lib/pdf.glsl\""), 0644)
+	later = later.Add(time.Second)
+	os.Chtimes(filepath.Join(dir, "gaussian.glsl"), later, later)
+	if _, err = watcher.Poll(); err != nil {
+		log.Println("E", err)
+	}
+	if len(watcher.Files()) != 4 {
+		log.Println("E", "nested includes are not watched", watcher.Files())
+	}
+	later = later.Add(time.Second)
+	os.Chtimes(filepath.Join(dir, "lib", "scale.glsl"), later, later)
+	if reloaded, _ := watcher.Poll(); !reloaded {
+		log.Println("E", "nested include change was not noticed")
+	}
+	FunctionsExample(compute, program)
+	if err = compute.DeleteProgram(program); err != nil {
+		log.Println("E", err)
+	}
+}
+
+func ProgramCacheExample(compute *gc.Computing, dir string) {
+	log.Println("D", "ProgramCacheExample started")
+	cache, err := gc.NewProgramCache(dir, 16<<20)
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	compute.SetProgramCache(cache)
+	//First load compiles and stores binary, second load reuses it
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		program := logLoad(compute, bufferTest)
+		log.Println("D", "load", i, "took", time.Since(start))
+		BufferExample(compute, program)
+		compute.DeleteProgram(program)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		log.Println("E", "expected single cache entry, got", len(entries))
+	}
+	compute.SetProgramCache(nil)
+}
+
 // Examples and testing for package functions
 func TestComputing(t *testing.T) {
 	compute, _ := gc.NewComputing()
@@ -347,6 +638,43 @@ func TestComputing(t *testing.T) {
 	TextureExample2(compute, textureProgram2)
 	//Include and functions examples
 	FunctionsExample(compute, functionsProgram)
+	//Multi-pass graph example
+	GraphExample(compute, bufferProgram)
+	//Iterative ping-pong example
+	PingPongExample(compute, bufferProgram)
+	//Indirect dispatch example
+	IndirectExample(compute, bufferProgram)
+	//Atomic counter append example
+	AppendExample(compute, logLoad(compute, appendTest))
+	//Parallel primitives example
+	PrimitivesExample(compute)
+	SortExample(compute)
+	//Dense linear algebra example
+	LinalgExample(compute)
+	//Image filtering example
+	ImgprocExample(compute)
+	//FFT example
+	FFTExample(compute)
+	//Histogram and statistics example
+	StatsExample(compute)
+	//Generated elementwise kernel example
+	ElementwiseExample(compute)
+	//Fused lazy expression example
+	ExprExample(compute)
+	//Work group autotuning example
+	AutotuneExample(compute)
+	//Device limits example
+	DeviceExample(compute)
+	//Resource tracking example
+	ResourcesExample(compute)
+	//Host image example
+	HostImageExample(compute)
+	//Buffer npy example
+	NPYBufferExample(compute, t.TempDir())
+	//Program hot reload example
+	HotReloadExample(compute, t.TempDir())
+	//Program binary cache example
+	ProgramCacheExample(compute, t.TempDir())
 
 	//SpeedTest(compute, bufferProgram)
 	//SpeedTest2(compute, speedProgram2)
@@ -355,4 +683,134 @@ func TestComputing(t *testing.T) {
 	//debugger1.StartWindow()
 	//for {
 	//}
+	//Frees programs and every object left open
+	compute.Close()
+}
+
+// Buffers and dispatches used from many goroutines through GL thread
+func TestComputingThread(t *testing.T) {
+	compute, err := gc.NewComputingThread(func() error {
+		if err := glfw.Init(); err != nil {
+			return err
+		}
+		glfw.WindowHint(glfw.ContextVersionMajor, 4)
+		glfw.WindowHint(glfw.ContextVersionMinor, 3)
+		glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+		glfw.WindowHint(glfw.OpenGLForwardCompatible, 1)
+		glfw.WindowHint(glfw.Visible, glfw.False)
+		window, err := glfw.CreateWindow(1, 1, "ComputingThread", nil, nil)
+		if err != nil {
+			return err
+		}
+		window.MakeContextCurrent()
+		return gl.Init()
+	})
+	if err != nil {
+		log.Println("E", "failed to start GL thread:", err)
+		return
+	}
+	defer compute.Close()
+	program := logLoad(compute, bufferTest)
+	done := make(chan bool)
+	for g := 0; g < 4; g++ {
+		go func(g int) {
+			input := []float32{float32(g), 1, 2, 3}
+			output := make([]float32, len(input))
+			//Do keeps program and bindings of this goroutine together
+			compute.Do(func() {
+				buffer := compute.NewBuffer()
+				buffer2 := compute.NewBuffer()
+				buffer.LoadFloat32(input)
+				buffer2.AllocateFloat32(len(input))
+				compute.UseProgram(program)
+				buffer.SetBinding(1)
+				buffer2.SetBinding(2)
+				compute.Realize(len(input), 1, 1)
+				gc.BufferReadInto(buffer2, output, 0)
+				buffer.Close()
+				buffer2.Close()
+			})
+			ok := true
+			for i := range input {
+				ok = ok && output[i] == input[i]+float32(i)
+			}
+			done <- ok
+		}(g)
+	}
+	for g := 0; g < 4; g++ {
+		if !<-done {
+			t.Error("wrong result from goroutine")
+		}
+	}
+	fence := compute.NewBatch().Add(func() { compute.UseProgram(program) }).Submit()
+	fence.Wait()
+}
+
+// Independent contexts per worker with buffer shared between them
+func TestSharedContexts(t *testing.T) {
+	first, err := glfwcontext.NewComputing()
+	if err != nil {
+		log.Println("E", "failed to create context:", err)
+		return
+	}
+	defer first.Close()
+	second, err := first.Shared()
+	if err != nil {
+		log.Println("E", "failed to create shared context:", err)
+		return
+	}
+	defer second.Close()
+	//Program ids are per Computing, both start from zero
+	program := logLoad(second, bufferTest)
+	input := []float32{1, 2, 3, 4}
+	buffer := first.NewBuffer()
+	buffer.LoadFloat32(input)
+	first.Finish()
+	output := make([]float32, len(input))
+	second.Do(func() {
+		buffer2 := second.NewBuffer()
+		buffer2.AllocateFloat32(len(input))
+		second.UseProgram(program)
+		//Buffer of first context bound in second context
+		buffer.SetBinding(1)
+		buffer2.SetBinding(2)
+		second.Realize(len(input), 1, 1)
+		gc.BufferReadInto(buffer2, output, 0)
+		buffer2.Close()
+	})
+	for i := range input {
+		if output[i] != input[i]+float32(i) {
+			t.Error("wrong shared result", i, output[i])
+		}
+	}
+	buffer.Close()
+}
+
+// Objects freed by Computing.Close report closed instead of reaching GL
+func TestUseAfterClose(t *testing.T) {
+	compute, err := glfwcontext.NewComputing()
+	if err != nil {
+		log.Println("E", "failed to create context:", err)
+		return
+	}
+	buffer := compute.NewBuffer()
+	buffer.LoadFloat32([]float32{1, 2, 3, 4})
+	texture := compute.NewTexture(gc.FLOAT32, 4)
+	texture.Create2D(2, 2)
+	compute.Close()
+	if data := gc.BufferRead[float32](buffer, 4); data != nil {
+		t.Error("BufferRead after Close returned", data)
+	}
+	if size := buffer.LoadFloat32([]float32{5}); size != 0 {
+		t.Error("LoadFloat32 after Close wrote", size, "bytes")
+	}
+	if err := gc.TextureReadInto(texture, make([]float32, 16)); err == nil {
+		t.Error("TextureReadInto after Close succeeded")
+	}
+	if err := texture.SetBinding(0); err == nil {
+		t.Error("SetBinding after Close succeeded")
+	}
+	//Closing freed objects is a no-op
+	buffer.Close()
+	texture.Close()
 }
@@ -0,0 +1,63 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestDeviceSupports(t *testing.T) {
+	device := gc.DeviceInfo{Renderer: "Test GPU", Version: "4.2.0", Major: 4, Minor: 2,
+		Extensions: []string{"GL_ARB_compute_shader", "GL_NV_shader_atomic_float"}}
+	if !device.AtLeast(4, 0) || !device.AtLeast(3, 3) || device.AtLeast(4, 3) || device.AtLeast(5, 0) {
+		t.Error("AtLeast of 4.2")
+	}
+	for feature, want := range map[gc.Feature]bool{
+		gc.FeatureCompute:           true,
+		gc.FeatureFloat64:           true,
+		gc.FeatureAtomicFloat:       true,
+		gc.FeatureSPIRV:             false,
+		gc.FeatureInt64:             false,
+		gc.FeatureProgramBinary:     false,
+		"GL_NV_shader_atomic_float": true,
+		"GL_ARB_bindless_texture":   false,
+	} {
+		if device.Supports(feature) != want {
+			t.Error("Supports", feature, "!=", want)
+		}
+	}
+	if err := device.Require(gc.FeatureCompute, gc.FeatureFloat64); err != nil {
+		t.Error(err)
+	}
+	err := device.Require(gc.FeatureCompute, gc.FeatureSPIRV, gc.FeatureInt64)
+	if err == nil || !strings.Contains(err.Error(), "Test GPU") || !strings.Contains(err.Error(), "spirv, int64") {
+		t.Error("Require error", err)
+	}
+}
+
+// DeviceExample Logging device description and checking limits required by compute
+func DeviceExample(compute *gc.Computing) {
+	log.Println("D", "DeviceExample started")
+	device := compute.DeviceInfo()
+	log.Println("D", device)
+	log.Println("D", "Max work group count", device.MaxWorkGroupCount, "size", device.MaxWorkGroupSize,
+		"invocations", device.MaxWorkGroupInvocations, "shared memory", device.MaxSharedMemory)
+	if !compute.Supports(gc.FeatureCompute) {
+		log.Println("E", "Device doesn't report compute support")
+	}
+	//Minimums guaranteed by GL 4.3
+	if device.MaxWorkGroupCount[0] < 65535 || device.MaxWorkGroupSize[0] < 1024 || device.MaxWorkGroupInvocations < 1024 ||
+		device.MaxSharedMemory < 32768 || device.MaxStorageBindings < 8 || device.MaxImageUnits < 8 {
+		log.Println("E", "Device limits below GL 4.3 minimums")
+	}
+	if !device.SupportsImageFormat(gc.FLOAT32, 4) {
+		log.Println("E", "rgba32f image format is not reported")
+	}
+	texture := compute.NewTexture(gc.FLOAT32, 4)
+	defer texture.Close()
+	texture.Create2D(1, 1)
+	if err := texture.SetBinding(device.MaxImageUnits); err == nil {
+		log.Println("E", "SetBinding accepted image unit out of range")
+	}
+}
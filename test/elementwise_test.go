@@ -0,0 +1,96 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"log"
+	"testing"
+)
+
+func TestElementwiseParams(t *testing.T) {
+	var compute *gc.Computing
+	invalid := [][]gc.ElementwiseParam{
+		{gc.ArgIn("x", "float")},
+		{gc.ArgIn("x", "float"), gc.ArgOut("x", "float")},
+		{gc.ArgOut("i", "float")},
+		{gc.ArgOut("gl_x", "float")},
+		{gc.ArgIn("x", "float"), gc.ArgOut("out", "float")},
+		{gc.ArgScalar("uniform", "float"), gc.ArgOut("y", "float")},
+		{gc.ArgOut("y", "vec3")},
+		{gc.ArgScalar("a", "mat4"), gc.ArgOut("y", "float")},
+		{gc.ElementwiseParam{Name: "a", Type: "float", Kind: gc.EScalar, Output: true}},
+	}
+	for _, params := range invalid {
+		if _, err := compute.NewElementwise("invalid", "y = x", params...); err == nil {
+			t.Error("NewElementwise accepted", params)
+		}
+	}
+	if _, err := compute.NewElementwise("saxpy", "z = a * x + y",
+		gc.ArgScalar("a", "float"), gc.ArgIn("x", "float"), gc.ArgIn("y", "float"), gc.ArgOut("z", "float")); err != nil {
+		t.Error(err)
+	}
+}
+
+// ElementwiseExample Running generated saxpy over buffers and in place image kernel with uint scalar
+func ElementwiseExample(compute *gc.Computing) {
+	log.Println("D", "ElementwiseExample started")
+	saxpy, err := compute.NewElementwise("saxpy", "z = a * x + y",
+		gc.ArgScalar("a", "float"), gc.ArgIn("x", "float"), gc.ArgIn("y", "float"), gc.ArgOut("z", "float"))
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	count := 100000
+	xs, ys := make([]float32, count), make([]float32, count)
+	for i := range xs {
+		xs[i], ys[i] = float32(i), float32(2*i)
+	}
+	x, y, z := compute.NewBuffer(), compute.NewBuffer(), compute.NewBuffer()
+	defer x.Close()
+	defer y.Close()
+	defer z.Close()
+	x.LoadFloat32(xs)
+	y.LoadFloat32(ys)
+	z.AllocateFloat32(count)
+	if err := saxpy.Run(0.5, x, y, z); err != nil {
+		log.Println("E", err)
+		return
+	}
+	result := z.ReadFloat32(count)
+	for i, v := range result {
+		if v != 0.5*xs[i]+ys[i] {
+			log.Println("E", "Elementwise saxpy", i, v)
+			break
+		}
+	}
+	if err := saxpy.Run(1, x, y); err == nil {
+		log.Println("E", "Elementwise accepted missing argument")
+	}
+
+	width, height := 37, 19
+	texture := compute.NewTexture(gc.FLOAT32, 4)
+	defer texture.Close()
+	texture.Create2D(width, height)
+	texels := make([]float32, width*height*4)
+	for i := range texels {
+		texels[i] = float32(i)
+	}
+	gc.TextureLoad2D(texture, texels)
+	shift, err := compute.NewElementwise("shift", "img = img * scale(k) + vec4(float(i))",
+		gc.ArgImageInOut("img"), gc.ArgScalar("k", "uint"))
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	shift.Preamble("float scale(uint k) {\n\treturn float(k) * 2.0;\n}")
+	if err := shift.Run(texture, uint32(3)); err != nil {
+		log.Println("E", err)
+		return
+	}
+	shifted := gc.TextureRead[float32](texture)
+	for i, v := range shifted {
+		if v != texels[i]*6+float32(i/4) {
+			log.Println("E", "Elementwise image", i, v)
+			break
+		}
+	}
+}
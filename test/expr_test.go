@@ -0,0 +1,81 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/eszdman/gocompute/expr"
+	"log"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestExprSource(t *testing.T) {
+	i := expr.Index()
+	shared := i.Mul(expr.F(2))
+	source, err := expr.Source(expr.Select(shared.Greater(expr.F(10)), shared, shared.Exp()), i.Cast(expr.Uint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(source, " * s0)") != 1 {
+		t.Error("Shared subexpression is not emitted once:", source)
+	}
+	if !strings.Contains(source, "out0 = ") || !strings.Contains(source, "out1 = ") {
+		t.Error("Outputs are not written:", source)
+	}
+	for _, e := range []*expr.Expr{
+		i.Greater(i),
+		i.Less(i).Add(i),
+		expr.Select(i, i, i),
+		i.Not(),
+		expr.Scalar(1.5),
+		i.Cast("double"),
+	} {
+		if _, err := expr.Source(e); err == nil {
+			t.Error("Source accepted invalid expression")
+		}
+	}
+	if _, err := expr.Source(i.Less(i).Cast(expr.Uint)); err != nil {
+		t.Error(err)
+	}
+}
+
+// ExprExample Fusing select, exp and user function over buffer into one pass
+func ExprExample(compute *gc.Computing) {
+	log.Println("D", "ExprExample started")
+	count := 10000
+	data := make([]float32, count)
+	for i := range data {
+		data[i] = float32(i%200-100) / 25
+	}
+	buffer := compute.NewBuffer()
+	defer buffer.Close()
+	buffer.LoadFloat32(data)
+	square := expr.Func("square", expr.Float, "float square(float v) {\n\treturn v * v;\n}")
+	x := expr.Buffer(buffer, expr.Float, count)
+	y := expr.Select(x.Greater(expr.F(0)), x.Exp(), expr.Call(square, x)).Add(expr.Index().Cast(expr.Float))
+	sign := x.Less(expr.F(0)).Cast(expr.Uint)
+	results, err := expr.New(compute).Eval(y, sign)
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	values := results[0].ReadFloat32(count)
+	signs := gc.BufferRead[uint32](results[1], count)
+	for i, v := range data {
+		want := v*v + float32(i)
+		if v > 0 {
+			want = float32(math.Exp(float64(v))) + float32(i)
+		}
+		if math.Abs(float64(values[i]-want)) > 1e-3*math.Max(1, math.Abs(float64(want))) {
+			log.Println("E", "Expr value", i, values[i], want)
+			break
+		}
+		if (signs[i] == 1) != (v < 0) {
+			log.Println("E", "Expr sign", i, signs[i])
+			break
+		}
+	}
+	for _, b := range results {
+		b.Close()
+	}
+}
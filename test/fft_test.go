@@ -0,0 +1,110 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/eszdman/gocompute/fft"
+	"log"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFFTReference(t *testing.T) {
+	if got := fft.Factors(120); !reflect.DeepEqual(got, []int{4, 2, 3, 5}) {
+		t.Error("Factors(120)", got)
+	}
+	if got := fft.Factors(1); len(got) != 0 {
+		t.Error("Factors(1)", got)
+	}
+	data := []gc.Vec2{{X: 1, Y: 0}, {X: 2, Y: -1}, {X: 0, Y: 3}, {X: -1, Y: 1}, {X: 4, Y: 0}}
+	if got := fft.DFT(fft.DFT(data, false), true); !complexClose(got, data, 1e-5) {
+		t.Error("DFT round trip", got)
+	}
+	impulse := make([]gc.Vec2, 12)
+	impulse[0] = gc.Vec2{X: 1}
+	for _, v := range fft.DFT2D(impulse, 4, 3, false) {
+		if math.Abs(float64(v.X-1)) > 1e-6 || math.Abs(float64(v.Y)) > 1e-6 {
+			t.Error("spectrum of impulse is not flat", v)
+		}
+	}
+}
+
+func complexClose(a, b []gc.Vec2, tolerance float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Hypot(float64(a[i].X-b[i].X), float64(a[i].Y-b[i].Y)) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// FFTExample Checking power of two, mixed radix, batched and 2D transforms against DFT
+func FFTExample(compute *gc.Computing) {
+	log.Println("D", "FFTExample started")
+	f := fft.New(compute)
+	for _, n := range []int{1, 8, 256, 360, 77, 1024} {
+		batches := 3
+		data := make([]gc.Vec2, n*batches)
+		for i := range data {
+			data[i] = gc.Vec2{X: float32(math.Sin(float64(i))), Y: float32(i%7) / 7}
+		}
+		input := compute.NewBuffer()
+		gc.BufferLoad(input, data)
+		output := compute.NewBuffer()
+		gc.BufferAllocate[gc.Vec2](output, n*batches)
+		if err := f.Forward(input, output, n, batches); err != nil {
+			log.Println("E", err)
+		}
+		spectrum := gc.BufferRead[gc.Vec2](output, n*batches)
+		for b := 0; b < batches; b++ {
+			want := fft.DFT(data[b*n:(b+1)*n], false)
+			if !complexClose(spectrum[b*n:(b+1)*n], want, 1e-3*math.Sqrt(float64(n))) {
+				log.Println("E", "FFT of size", n, "differs from DFT, batch:", b)
+			}
+		}
+		//In place inverse restores input
+		if err := f.Inverse(output, output, n, batches); err != nil {
+			log.Println("E", err)
+		}
+		if !complexClose(gc.BufferRead[gc.Vec2](output, n*batches), data, 1e-4) {
+			log.Println("E", "Inverse FFT of size", n, "doesn't restore input")
+		}
+		input.Close()
+		output.Close()
+	}
+	width, height := 12, 16
+	data := make([]gc.Vec2, width*height)
+	real := make([]float32, width*height)
+	for i := range data {
+		real[i] = float32(i%11) - 5
+		data[i] = gc.Vec2{X: real[i]}
+	}
+	input := compute.NewBuffer()
+	gc.BufferLoad(input, data)
+	output := compute.NewBuffer()
+	gc.BufferAllocate[gc.Vec2](output, len(data))
+	if err := f.Forward2D(input, output, width, height); err != nil {
+		log.Println("E", err)
+	}
+	if !complexClose(gc.BufferRead[gc.Vec2](output, len(data)), fft.DFT2D(data, width, height, false), 1e-2) {
+		log.Println("E", "2D FFT differs from DFT")
+	}
+	realBuffer := compute.NewBuffer()
+	realBuffer.LoadFloat32(real)
+	if err := f.ForwardReal(realBuffer, output, width, height); err != nil {
+		log.Println("E", err)
+	}
+	if err := f.InverseReal(output, realBuffer, width, height); err != nil {
+		log.Println("E", err)
+	}
+	if !closeTo(realBuffer.ReadFloat32(len(real)), real, 1e-4) {
+		log.Println("E", "Real FFT round trip differs")
+	}
+	input.Close()
+	output.Close()
+	realBuffer.Close()
+	f.Close()
+}
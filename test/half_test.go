@@ -0,0 +1,65 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"math"
+	"testing"
+)
+
+func TestHalf(t *testing.T) {
+	cases := []struct {
+		f float32
+		h gc.Half
+	}{
+		{0, 0x0000},
+		{float32(math.Copysign(0, -1)), 0x8000},
+		{1, 0x3C00},
+		{-2, 0xC000},
+		{65504, 0x7BFF},
+		{65520, 0x7C00},
+		{1e6, 0x7C00},
+		{float32(math.Inf(-1)), 0xFC00},
+		//Smallest subnormal and normal
+		{5.9604645e-08, 0x0001},
+		{6.1035156e-05, 0x0400},
+		//Half of smallest subnormal ties to even zero, above it rounds up
+		{2.9802322e-08, 0x0000},
+		{2.9802326e-08, 0x0001},
+		//1 + 2^-11 ties to even 1, 1 + 3*2^-11 ties up
+		{1.00048828125, 0x3C00},
+		{1.00146484375, 0x3C02},
+		{0.333333333, 0x3555},
+	}
+	for _, c := range cases {
+		if h := gc.HalfFromFloat32(c.f); h != c.h {
+			t.Errorf("HalfFromFloat32(%g) = %#04x instead of %#04x", c.f, h, c.h)
+		}
+	}
+	if !gc.HalfFromFloat32(float32(math.NaN())).IsNaN() {
+		t.Error("NaN lost")
+	}
+	if !gc.HalfInf.IsInf() || gc.HalfNaN.IsInf() {
+		t.Error("wrong IsInf")
+	}
+	//Every finite half converts to float32 and back exactly
+	for i := 0; i < 0x10000; i++ {
+		h := gc.Half(i)
+		if h.IsNaN() {
+			if !math.IsNaN(float64(h.Float32())) {
+				t.Fatalf("%#04x is not NaN", i)
+			}
+			continue
+		}
+		if back := gc.HalfFromFloat32(h.Float32()); back != h {
+			t.Fatalf("%#04x -> %g -> %#04x", i, h.Float32(), back)
+		}
+	}
+	x, y := gc.UnpackHalf2x16(gc.PackHalf2x16(0.5, -3))
+	if x != 0.5 || y != -3 || gc.PackHalf2x16(1, 0) != 0x3C00 {
+		t.Error("wrong half packing", x, y)
+	}
+	values := gc.FromHalf(gc.ToHalf([]float32{0.25, 1024, -0.125}))
+	if values[0] != 0.25 || values[1] != 1024 || values[2] != -0.125 {
+		t.Error("wrong bulk conversion", values)
+	}
+}
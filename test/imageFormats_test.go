@@ -0,0 +1,99 @@
+package test
+
+import (
+	"bytes"
+	gc "github.com/eszdman/gocompute"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func roundTrip(t *testing.T, img *gc.HostImage, write func(io.Writer, *gc.HostImage) error,
+	read func(io.Reader) (*gc.HostImage, error)) *gc.HostImage {
+	var buf bytes.Buffer
+	if err := write(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	out, err := read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Width != img.Width || out.Height != img.Height || out.Channels != img.Channels {
+		t.Fatal("wrong dimensions", out.Width, out.Height, out.Channels)
+	}
+	return out
+}
+
+func TestImageFormats(t *testing.T) {
+	floats := []float32{0, 0.5, 1, 2, -1, 1e-3}
+	pfm, _ := gc.HostImageOf(floats, 2, 3, 1, 1, gc.FLOAT32)
+	out := roundTrip(t, pfm, gc.WritePFM, gc.ReadPFM)
+	for i, v := range gc.HostImageData[float32](out) {
+		if v != floats[i] {
+			t.Error("PFM value", i, v, "instead of", floats[i])
+		}
+	}
+	half, _ := gc.HostImageOf(floats, 2, 3, 1, 1, gc.FLOAT16)
+	if err := gc.WritePFM(&bytes.Buffer{}, half); err == nil {
+		t.Error("PFM accepted FLOAT16 image")
+	}
+
+	wide := []uint16{0, 1, 4095, 65535, 300, 2, 7, 8, 9, 10, 11, 12}
+	for _, channels := range []int{1, 3} {
+		img, err := gc.HostImageOf(wide, 6/channels, 2, 1, channels, gc.UNSIGNED16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, codec := range []struct {
+			write func(io.Writer, *gc.HostImage) error
+			read  func(io.Reader) (*gc.HostImage, error)
+		}{{gc.WritePNM, gc.ReadPNM}, {gc.WritePNG, gc.ReadPNG}} {
+			out = roundTrip(t, img, codec.write, codec.read)
+			if !bytes.Equal(out.Data, img.Data) {
+				t.Error("16 bit data mismatch", channels, gc.HostImageData[uint16](out))
+			}
+		}
+	}
+
+	narrow := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	img, _ := gc.HostImageOf(narrow, 2, 1, 1, 4, gc.SIMPLE8)
+	out = roundTrip(t, img, gc.WritePNG, gc.ReadPNG)
+	if !bytes.Equal(out.Data, narrow) {
+		t.Error("8 bit PNG mismatch", out.Data)
+	}
+	if err := gc.WritePNM(&bytes.Buffer{}, img); err == nil {
+		t.Error("PNM accepted 4 channels")
+	}
+
+	//Sizes from untrusted headers must not allocate before data is present
+	for _, header := range []string{"Pf\n100000000 100000000\n-1.0\n", "PF\n4611686018427387904 4\n-1.0\n",
+		"P5\n100000000 100000000\n255\n", "P6\n2 2\n65535\n"} {
+		read := gc.ReadPNM
+		if header[1] == 'f' || header[1] == 'F' {
+			read = gc.ReadPFM
+		}
+		if _, err := read(strings.NewReader(header + "\x01\x02")); err == nil {
+			t.Error("truncated image accepted", strings.Fields(header))
+		}
+	}
+	if _, err := gc.NewHostImage(-1, 2, 1, 1, gc.FLOAT32); err == nil {
+		t.Error("negative image size accepted")
+	}
+
+	dir := t.TempDir()
+	for _, layout := range []gc.RawLayout{gc.RawInterleaved, gc.RawPlanar} {
+		img, _ = gc.HostImageOf(wide, 2, 2, 1, 3, gc.SIMPLE16)
+		path := filepath.Join(dir, string(layout)+".raw")
+		if err := gc.WriteRaw(path, img, layout); err != nil {
+			t.Fatal(err)
+		}
+		out, err := gc.ReadRaw(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Type != gc.SIMPLE16 || !bytes.Equal(out.Data, img.Data) {
+			t.Error("raw mismatch", layout, gc.HostImageData[uint16](out))
+		}
+	}
+}
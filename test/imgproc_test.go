@@ -0,0 +1,99 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/eszdman/gocompute/imgproc"
+	"log"
+	"reflect"
+	"testing"
+)
+
+func TestImgprocReference(t *testing.T) {
+	data := []float32{1, 2, 3}
+	shift := []float32{1, 0, 0}
+	//Sample left of each pixel
+	cases := map[imgproc.Border][]float32{
+		imgproc.BorderClamp:   {1, 1, 2},
+		imgproc.BorderReflect: {2, 1, 2},
+		imgproc.BorderZero:    {0, 1, 2},
+	}
+	for border, want := range cases {
+		if got := imgproc.ConvolveCPU(data, 3, 1, 1, shift, 3, 1, border); !reflect.DeepEqual(got, want) {
+			t.Error("border", border, got, want)
+		}
+	}
+	weights := imgproc.GaussianKernel(1)
+	total := float32(0)
+	for _, w := range weights {
+		total += w
+	}
+	if len(weights) != 7 || total < 0.999 || total > 1.001 || weights[3] < weights[2] {
+		t.Error("GaussianKernel", weights)
+	}
+}
+
+// ImgprocExample Checking filters against CPU convolution for every border mode
+func ImgprocExample(compute *gc.Computing) {
+	log.Println("D", "ImgprocExample started")
+	p := imgproc.New(compute)
+	defer p.Close()
+	width, height := 37, 21
+	data := make([]float32, width*height*4)
+	for i := range data {
+		data[i] = float32(i*7%23) / 23
+	}
+	src := compute.NewTexture(gc.FLOAT32, 4)
+	src.Create2D(width, height)
+	src.Load2DFloat32(data)
+	defer src.Close()
+	weights := imgproc.GaussianKernel(1.5)
+	kernel := make([]float32, len(weights)*len(weights))
+	for y := range weights {
+		for x := range weights {
+			kernel[y*len(weights)+x] = weights[y] * weights[x]
+		}
+	}
+	for _, border := range []imgproc.Border{imgproc.BorderClamp, imgproc.BorderReflect, imgproc.BorderZero} {
+		blurred, err := p.GaussianBlur(src, nil, 1.5, border)
+		if err != nil {
+			log.Println("E", err)
+			continue
+		}
+		want := imgproc.ConvolveCPU(data, width, height, 4, kernel, len(weights), len(weights), border)
+		if !closeTo(blurred.ReadFloat32(), want, 1e-4) {
+			log.Println("E", "GaussianBlur differs from CPU convolution, border:", border)
+		}
+		blurred.Close()
+	}
+	same, err := p.Resize(src, nil, width, height, imgproc.Bicubic, imgproc.BorderClamp)
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	defer same.Close()
+	if !closeTo(same.ReadFloat32(), data, 1e-5) {
+		log.Println("E", "Resize to same size changes image")
+	}
+	ycbcr, err := p.ConvertColor(src, nil, imgproc.RGBToYCbCr)
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	defer ycbcr.Close()
+	rgb, err := p.ConvertColor(ycbcr, same, imgproc.YCbCrToRGB)
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	if !closeTo(rgb.ReadFloat32(), data, 1e-4) {
+		log.Println("E", "YCbCr round trip changes image")
+	}
+	for _, size := range []int{3, 5} {
+		median, err := p.Median(src, nil, size, imgproc.BorderReflect)
+		if err != nil {
+			log.Println("E", err)
+			continue
+		}
+		median.Close()
+	}
+}
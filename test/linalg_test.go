@@ -0,0 +1,95 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/eszdman/gocompute/linalg"
+	"log"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestLinalgReference(t *testing.T) {
+	a := []float32{1, 2, 3, 4, 5, 6}
+	b := []float32{7, 8, 9, 10, 11, 12}
+	c := []float32{1, 1, 1, 1}
+	linalg.GemmCPU(2, 2, 3, 1, a, b, 2, c)
+	if !reflect.DeepEqual(c, []float32{60, 66, 141, 156}) {
+		t.Error("GemmCPU", c)
+	}
+	y := []float32{0, 0}
+	linalg.GemvCPU(2, 3, 2, a, []float32{1, 0, 1}, 0, y)
+	if !reflect.DeepEqual(y, []float32{8, 20}) {
+		t.Error("GemvCPU", y)
+	}
+	if got := linalg.TransposeCPU(2, 3, a); !reflect.DeepEqual(got, []float32{1, 4, 2, 5, 3, 6}) {
+		t.Error("TransposeCPU", got)
+	}
+}
+
+func closeTo(a, b []float32, tolerance float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > tolerance*math.Max(1, math.Abs(float64(b[i]))) {
+			return false
+		}
+	}
+	return true
+}
+
+// LinalgExample Checking kernels against CPU references with sizes not multiple of tile
+func LinalgExample(compute *gc.Computing) {
+	log.Println("D", "LinalgExample started")
+	l := linalg.New(compute)
+	log.Println("D", "linalg tile:", l.Tile())
+	m, n, k := 67, 45, 129
+	a := make([]float32, m*k)
+	b := make([]float32, k*n)
+	for i := range a {
+		a[i] = float32(i%13) - 6
+	}
+	for i := range b {
+		b[i] = float32(i%7) * 0.5
+	}
+	matrixA, _ := l.MatrixFrom(m, k, linalg.RowMajor, a)
+	//Column major storage of B is row major storage of its transpose
+	matrixB, _ := l.MatrixFrom(k, n, linalg.ColMajor, linalg.TransposeCPU(k, n, b))
+	matrixC := l.NewMatrix(m, n, linalg.RowMajor)
+	want := make([]float32, m*n)
+	linalg.GemmCPU(m, n, k, 1, a, b, 0, want)
+	if err := l.MatMul(matrixA, matrixB, matrixC); err != nil || !closeTo(matrixC.Read(), want, 1e-4) {
+		log.Println("E", "Gemm differs from CPU reference", err)
+	}
+	x := make([]float32, k)
+	for i := range x {
+		x[i] = float32(i%5) - 2
+	}
+	vectorX := l.VectorFrom(x)
+	vectorY := l.NewVector(m)
+	wantY := make([]float32, m)
+	linalg.GemvCPU(m, k, 1, a, x, 0, wantY)
+	if err := l.Gemv(1, matrixA, false, vectorX, 0, vectorY); err != nil || !closeTo(vectorY.Read(), wantY, 1e-4) {
+		log.Println("E", "Gemv differs from CPU reference", err)
+	}
+	transposed := l.NewMatrix(k, m, linalg.RowMajor)
+	if err := l.Transpose(matrixA, transposed); err != nil || !reflect.DeepEqual(transposed.Read(), linalg.TransposeCPU(m, k, a)) {
+		log.Println("E", "Transpose differs from CPU reference", err)
+	}
+	if err := l.Axpy(2, vectorY, vectorY); err != nil {
+		log.Println("E", err)
+	}
+	for i := range wantY {
+		wantY[i] *= 3
+	}
+	if !closeTo(vectorY.Read(), wantY, 1e-4) {
+		log.Println("E", "Axpy differs from CPU reference")
+	}
+	for _, matrix := range []*linalg.Matrix{matrixA, matrixB, matrixC, transposed} {
+		matrix.Close()
+	}
+	vectorX.Close()
+	vectorY.Close()
+	l.Close()
+}
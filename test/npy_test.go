@@ -0,0 +1,97 @@
+package test
+
+import (
+	"bytes"
+	gc "github.com/eszdman/gocompute"
+	"path/filepath"
+	"testing"
+)
+
+func TestNPY(t *testing.T) {
+	var buf bytes.Buffer
+	points := []gc.Vec2{{X: 1, Y: 2}, {X: 3, Y: 4}, {X: 5, Y: 6}}
+	if err := gc.WriteNPY(&buf, points); err != nil {
+		t.Fatal(err)
+	}
+	if (buf.Len()-2*4*len(points))%64 != 0 {
+		t.Error("data is not aligned to 64 bytes")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("'descr': '<f4', 'fortran_order': False, 'shape': (3, 2), }")) {
+		t.Error("wrong header", buf.String()[:64])
+	}
+	read, shape, err := gc.ReadNPY[gc.Vec2](&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shape) != 2 || shape[0] != 3 || shape[1] != 2 || len(read) != 3 || read[2] != points[2] {
+		t.Error("wrong round trip", shape, read)
+	}
+
+	buf.Reset()
+	gc.WriteNPY(&buf, []int32{1, 2})
+	if _, _, err = gc.ReadNPY[float32](&buf); err == nil {
+		t.Error("dtype mismatch accepted")
+	}
+
+	img, _ := gc.HostImageOf([]uint16{1, 2, 3, 4, 5, 6}, 3, 1, 1, 2, gc.UNSIGNED16)
+	buf.Reset()
+	if err = gc.WriteHostImageNPY(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	out, err := gc.ReadHostImageNPY(&buf, gc.UNSIGNED16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Width != 3 || out.Height != 1 || out.Depth != 1 || out.Channels != 2 || !bytes.Equal(out.Data, img.Data) {
+		t.Error("wrong image round trip", out)
+	}
+
+	halfImg, _ := gc.HostImageOf([]float32{0.5, -2, 1}, 3, 1, 1, 1, gc.FLOAT16)
+	buf.Reset()
+	if err = gc.WriteHostImageNPY(&buf, halfImg); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("'<f2'")) {
+		t.Error("FLOAT16 image not stored as half array")
+	}
+	out, err = gc.ReadHostImageNPY(&buf, gc.NONE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Type != gc.FLOAT16 || !bytes.Equal(out.Data, halfImg.Data) {
+		t.Error("wrong half image round trip", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.npz")
+	z, err := gc.CreateNPZ(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gc.NPZWriteSlice(z, "a", []float32{1, 2, 3, 4}, 2, 2)
+	gc.NPZWriteSlice(z, "b", []uint8{7})
+	if err = z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := gc.OpenNPZ(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if names := r.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Error("wrong names", names)
+	}
+	a, shape, err := gc.NPZReadSlice[float32](r, "a")
+	if err != nil || len(shape) != 2 || a[3] != 4 {
+		t.Error("wrong npz array", a, shape, err)
+	}
+
+	//Shapes from untrusted headers are validated before any allocation
+	for _, shape := range []string{"(-1,)", "(4611686018427387904, 4611686018427387904)", "(4611686018427387904,)", "(1000000,)"} {
+		header := "{'descr': '<f4', 'fortran_order': False, 'shape': " + shape + ", }\n"
+		raw := append([]byte("\x93NUMPY\x01\x00"), byte(len(header)), byte(len(header)>>8))
+		raw = append(append(raw, header...), 1, 2, 3, 4)
+		if _, _, err := gc.ReadNPY[float32](bytes.NewReader(raw)); err == nil {
+			t.Error("malformed shape accepted", shape)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/eszdman/gocompute/primitives"
+	"log"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPrimitivesReference(t *testing.T) {
+	data := []int32{3, -1, 4, 1, -5, 9}
+	sum, identity, err := primitives.CPUOperator[int32](primitives.Sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := primitives.ReduceCPU(data, sum, identity); got != 11 {
+		t.Error("sum", got)
+	}
+	minimum, identity, _ := primitives.CPUOperator[int32](primitives.Min)
+	if got := primitives.ReduceCPU(data, minimum, identity); got != -5 {
+		t.Error("min", got)
+	}
+	maximum, floatIdentity, _ := primitives.CPUOperator[float32](primitives.Max)
+	if got := primitives.ReduceCPU([]float32{}, maximum, floatIdentity); got > -1e38 {
+		t.Error("max of empty input is not identity", got)
+	}
+	if got := primitives.ScanCPU(data, sum, 0, false); !reflect.DeepEqual(got, []int32{3, 2, 6, 7, 2, 11}) {
+		t.Error("inclusive scan", got)
+	}
+	if got := primitives.ScanCPU(data, sum, 0, true); !reflect.DeepEqual(got, []int32{0, 3, 2, 6, 7, 2}) {
+		t.Error("exclusive scan", got)
+	}
+	if got := primitives.CompactCPU(data, func(x int32) bool { return x > 0 }); !reflect.DeepEqual(got, []int32{3, 4, 1, 9}) {
+		t.Error("compact", got)
+	}
+	if _, _, err = primitives.CPUOperator[int32](primitives.Custom("xor", "a ^ b", "0")); err == nil {
+		t.Error("custom operator has no CPU version")
+	}
+}
+
+// PrimitivesExample Checking GPU primitives against CPU references on multi-pass sizes
+func PrimitivesExample(compute *gc.Computing) {
+	log.Println("D", "PrimitivesExample started")
+	p := primitives.New(compute)
+	n := 300000
+	data := make([]int32, n)
+	for i := range data {
+		data[i] = int32(i*7919%1000) - 500
+	}
+	input := compute.NewBuffer()
+	input.LoadInt32(data)
+	output := compute.NewBuffer()
+	output.AllocateInt32(n)
+	for _, op := range []primitives.Operator{primitives.Sum, primitives.Min, primitives.Max} {
+		combine, identity, _ := primitives.CPUOperator[int32](op)
+		got, err := primitives.Reduce[int32](p, input, n, op)
+		if want := primitives.ReduceCPU(data, combine, identity); err != nil || got != want {
+			log.Println("E", "Reduce", op.Name, "got:", got, "expected:", want, err)
+		}
+	}
+	sum, _, _ := primitives.CPUOperator[int32](primitives.Sum)
+	for _, exclusive := range []bool{false, true} {
+		if err := primitives.Scan[int32](p, input, output, n, primitives.Sum, exclusive); err != nil {
+			log.Println("E", err)
+		}
+		want := primitives.ScanCPU(data, sum, 0, exclusive)
+		if got := output.ReadInt32(n); !reflect.DeepEqual(got, want) {
+			log.Println("E", "Scan exclusive:", exclusive, "differs from CPU reference")
+		}
+	}
+	count, err := primitives.Compact[int32](p, input, output, n, "x > 0")
+	want := primitives.CompactCPU(data, func(x int32) bool { return x > 0 })
+	if err != nil || count != len(want) || !reflect.DeepEqual(output.ReadInt32(count), want) {
+		log.Println("E", "Compact differs from CPU reference", count, len(want), err)
+	}
+	input.Close()
+	output.Close()
+	p.Close()
+}
+
+// SortExample Sorting keys and key value pairs of length not multiple of block size
+func SortExample(compute *gc.Computing) {
+	log.Println("D", "SortExample started")
+	p := primitives.New(compute)
+	n := 100003
+	keys := make([]uint32, n)
+	floats := make([]float32, n)
+	values := make([]uint32, n)
+	for i := range keys {
+		keys[i] = uint32(i) * 2654435761
+		floats[i] = float32(math.Sin(float64(i))) * 1000
+		values[i] = uint32(i)
+	}
+	keyBuffer := compute.NewBuffer()
+	gc.BufferLoad(keyBuffer, keys)
+	if err := primitives.SortUint32(p, keyBuffer, n); err != nil {
+		log.Println("E", err)
+	}
+	sorted := append([]uint32(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if !reflect.DeepEqual(gc.BufferRead[uint32](keyBuffer, n), sorted) {
+		log.Println("E", "SortUint32 differs from sort.Slice")
+	}
+	floatBuffer := compute.NewBuffer()
+	floatBuffer.LoadFloat32(floats)
+	valueBuffer := compute.NewBuffer()
+	gc.BufferLoad(valueBuffer, values)
+	if err := primitives.SortByFloat32Key(p, floatBuffer, valueBuffer, n); err != nil {
+		log.Println("E", err)
+	}
+	order := append([]uint32(nil), values...)
+	sort.SliceStable(order, func(i, j int) bool { return floats[order[i]] < floats[order[j]] })
+	if !reflect.DeepEqual(gc.BufferRead[uint32](valueBuffer, n), order) {
+		log.Println("E", "SortByFloat32Key values differ from stable sort")
+	}
+	keyBuffer.Close()
+	floatBuffer.Close()
+	valueBuffer.Close()
+	p.Close()
+}
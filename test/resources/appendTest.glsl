@@ -0,0 +1,18 @@
+layout(std430, binding = 1) buffer inputBuffer {
+	float inputValues[];
+};
+layout(std430, binding = 2) buffer outputBuffer {
+	float outputValues[];
+};
+layout(binding = 0, offset = 0) uniform atomic_uint outputCount;
+uniform int capacity;
+layout(local_size_x = 1, local_size_y = 1, local_size_z = 1) in;
+void main() {
+	int idx = int(gl_GlobalInvocationID.x);
+	if (inputValues[idx] > 4.0) {
+		uint i = atomicCounterIncrement(outputCount);
+		if (i < uint(capacity)) {
+			outputValues[i] = inputValues[idx];
+		}
+	}
+}
@@ -0,0 +1,18 @@
+#define LOCAL_SIZE_X 1
+#define LOCAL_SIZE_Y 1
+#define LOCAL_SIZE_Z 1
+layout(std430, binding = 1) readonly buffer inputBuffer {
+	float values[];
+};
+layout(std430, binding = 2) writeonly buffer outputBuffer {
+	float results[];
+};
+uniform int count;
+layout(local_size_x = LOCAL_SIZE_X, local_size_y = LOCAL_SIZE_Y, local_size_z = LOCAL_SIZE_Z) in;
+void main() {
+	int i = int(gl_GlobalInvocationID.x);
+	if (i >= count) {
+		return;
+	}
+	results[i] = values[i] * 2.0 + 1.0;
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"encoding/binary"
+	gc "github.com/eszdman/gocompute"
+	"testing"
+)
+
+// spirvWords Instruction with word count in high half of first word
+func spirvWords(op uint32, args ...uint32) []uint32 {
+	return append([]uint32{uint32(len(args)+1)<<16 | op}, args...)
+}
+
+func TestReflectSPIRV(t *testing.T) {
+	//"main" and "scale" as null terminated little endian words
+	main := []uint32{0x6E69616D, 0}
+	scale := []uint32{0x6C616373, 0x65}
+	words := []uint32{0x07230203, 0x00010000, 0, 10, 0}
+	words = append(words, spirvWords(15, append([]uint32{5, 4}, main...)...)...)
+	words = append(words, spirvWords(16, 4, 17, 8, 4, 1)...)
+	words = append(words, spirvWords(5, append([]uint32{7}, scale...)...)...)
+	words = append(words, spirvWords(71, 7, 1, 3)...)
+	words = append(words, spirvWords(71, 8, 1, 5)...)
+	words = append(words, spirvWords(50, 6, 7, gc.SpecFloat(1.5))...)
+	words = append(words, spirvWords(48, 9, 8)...)
+	code := make([]byte, 0, len(words)*4)
+	for _, w := range words {
+		code = binary.LittleEndian.AppendUint32(code, w)
+	}
+	info, err := gc.ReflectSPIRV(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.EntryPoints) != 1 || info.EntryPoints[0] != "main" {
+		t.Error("wrong entry points", info.EntryPoints)
+	}
+	if info.LocalSize["main"] != [3]int{8, 4, 1} {
+		t.Error("wrong local size", info.LocalSize)
+	}
+	if info.SpecConstants["scale"] != 3 || info.SpecConstants["#5"] != 5 {
+		t.Error("wrong specialization constants", info.SpecConstants)
+	}
+	if ids := info.SpecIDs(); len(ids) != 2 || ids[0] != 3 || ids[1] != 5 {
+		t.Error("wrong specialization ids", ids)
+	}
+	if _, err = gc.ReflectSPIRV(code[:len(code)-4]); err == nil {
+		t.Error("truncated module accepted")
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/eszdman/gocompute/stats"
+	"log"
+	"math"
+	"testing"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	h := &stats.Histogram{Low: 0, High: 4, Bins: [][]uint32{{1, 1, 1, 1}, {0, 0, 0, 0}}}
+	if h.Total(0) != 4 {
+		t.Error("Total", h.Total(0))
+	}
+	for p, want := range map[float64]float32{0: 0, 0.25: 1, 0.5: 2, 0.6: 2.4, 1: 4} {
+		if got := h.Percentile(0, p); math.Abs(float64(got-want)) > 1e-6 {
+			t.Error("Percentile", p, got, want)
+		}
+	}
+	if got := h.Percentile(1, 0.5); !math.IsNaN(float64(got)) {
+		t.Error("Percentile of empty channel", got)
+	}
+}
+
+// StatsExample Checking moments and histogram of two channel buffer and texture against Go
+func StatsExample(compute *gc.Computing) {
+	log.Println("D", "StatsExample started")
+	s := stats.New(compute)
+	count := 100000
+	data := make([]float32, count*2)
+	for i := 0; i < count; i++ {
+		data[2*i] = float32(i % 100)
+		data[2*i+1] = float32(math.Sin(float64(i)))
+	}
+	buffer := compute.NewBuffer()
+	buffer.LoadFloat32(data)
+	moments, err := s.Moments(stats.BufferSource(buffer, count, 2))
+	if err != nil {
+		log.Println("E", err)
+	} else if m := moments[0]; m.Count != count || m.Min != 0 || m.Max != 99 || math.Abs(m.Mean-49.5) > 1e-3 || math.Abs(m.Variance-833.25) > 1e-1 {
+		log.Println("E", "Moments differ from expected", m)
+	}
+	h, err := s.Histogram(stats.BufferSource(buffer, count, 2), 10, 0, 100)
+	if err != nil {
+		log.Println("E", err)
+	} else {
+		for _, v := range h.Bins[0] {
+			if v != uint32(count/10) {
+				log.Println("E", "Histogram bin differs", h.Bins[0])
+				break
+			}
+		}
+	}
+	percentiles, err := s.Percentiles(stats.BufferSource(buffer, count, 2), 1000, 0.5)
+	if err != nil || math.Abs(float64(percentiles[0][0]-49.5)) > 1 {
+		log.Println("E", "Median estimate differs", percentiles, err)
+	}
+	texture := compute.NewTexture(gc.FLOAT32, 2)
+	texture.Create2D(count/200, 200)
+	texture.Load2DFloat32(data)
+	textureMoments, err := s.Moments(stats.TextureSource(texture))
+	if err != nil || len(textureMoments) != 2 || math.Abs(textureMoments[1].Mean-moments[1].Mean) > 1e-4 {
+		log.Println("E", "Texture moments differ from buffer moments", textureMoments, err)
+	}
+	buffer.Close()
+	texture.Close()
+	s.Close()
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"github.com/go-gl/gl/all-core/gl"
+	"testing"
+)
+
+func TestTextureFormatOf(t *testing.T) {
+	cases := []struct {
+		texType  gc.TextureType
+		channels int
+		internal uint32
+		format   uint32
+		xType    uint32
+		size     int
+		image    bool
+	}{
+		{gc.SIGNED16, 1, gl.R16I, gl.RED_INTEGER, gl.SHORT, 2, true},
+		{gc.UNSIGNED16, 4, gl.RGBA16UI, gl.RGBA_INTEGER, gl.UNSIGNED_SHORT, 8, true},
+		{gc.SIGNED8, 2, gl.RG8I, gl.RG_INTEGER, gl.BYTE, 2, true},
+		{gc.UNSIGNED8, 4, gl.RGBA8UI, gl.RGBA_INTEGER, gl.UNSIGNED_BYTE, 4, true},
+		{gc.SIMPLE16, 1, gl.R16, gl.RED, gl.UNSIGNED_SHORT, 2, true},
+		{gc.FLOAT32, 3, gl.RGB32F, gl.RGB, gl.FLOAT, 12, false},
+		{gc.R11G11B10F, 3, gl.R11F_G11F_B10F, gl.RGB, gl.UNSIGNED_INT_10F_11F_11F_REV, 4, true},
+		{gc.SRGB8A8, 4, gl.SRGB8_ALPHA8, gl.RGBA, gl.UNSIGNED_BYTE, 4, false},
+		{gc.DEPTH32F, 1, gl.DEPTH_COMPONENT32F, gl.DEPTH_COMPONENT, gl.FLOAT, 4, false},
+	}
+	for _, c := range cases {
+		f, err := gc.TextureFormatOf(c.texType, c.channels)
+		if err != nil {
+			t.Fatal(c.texType, c.channels, err)
+		}
+		if f.Internal != c.internal || f.Format != c.format || f.XType != c.xType || f.TexelSize != c.size || f.ImageLoadStore() != c.image {
+			t.Error("wrong format for", c.texType, c.channels, f)
+		}
+	}
+	invalid := []struct {
+		texType  gc.TextureType
+		channels int
+	}{{gc.FLOAT32, 0}, {gc.FLOAT32, 5}, {gc.NONE, 1}, {gc.RGB10A2, 3}, {gc.DEPTH16, 4}}
+	for _, c := range invalid {
+		if _, err := gc.TextureFormatOf(c.texType, c.channels); err == nil {
+			t.Error("accepted invalid combination", c.texType, c.channels)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	gc "github.com/eszdman/gocompute"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTuningDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuning", "db.json")
+	db, err := gc.OpenTuningDB(path)
+	if err != nil || db.Len() != 0 {
+		t.Fatal("OpenTuningDB of missing file", err)
+	}
+	entry := gc.TuningEntry{Kernel: "kernel", Device: "device", Group: gc.WorkGroup{X: 64, Y: 2, Z: 1}, Nanoseconds: 1000}
+	if err := db.Store(entry); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := gc.OpenTuningDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reopened.Lookup("kernel", "device"); !ok || got != entry {
+		t.Error("Lookup after reopening", got, ok)
+	}
+	if _, ok := reopened.Lookup("kernel", "other device"); ok {
+		t.Error("Lookup matched other device")
+	}
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gc.OpenTuningDB(path); err == nil {
+		t.Error("OpenTuningDB accepted corrupted file")
+	}
+}
+
+// AutotuneExample Tuning local size of buffer kernel and loading it with stored size
+func AutotuneExample(compute *gc.Computing) {
+	log.Println("D", "AutotuneExample started")
+	db, err := gc.OpenTuningDB(filepath.Join(os.TempDir(), "gocompute-tuning-example.json"))
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	compute.SetTuningDB(db)
+	defer compute.SetTuningDB(nil)
+	count := 1 << 20
+	values := make([]float32, count)
+	for i := range values {
+		values[i] = float32(i)
+	}
+	input, output := compute.NewBuffer(), compute.NewBuffer()
+	defer input.Close()
+	defer output.Close()
+	input.LoadFloat32(values)
+	output.AllocateFloat32(count)
+	best, err := compute.Autotune(tuneTest, gc.Candidates1D(), 3, func(program int, group gc.WorkGroup) error {
+		input.SetBinding(1)
+		output.SetBinding(2)
+		compute.SetInt("count", count)
+		compute.Realize((count+group.X-1)/group.X, 1, 1)
+		return nil
+	})
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	log.Println("D", "Best work group", best)
+	program := logLoad(compute, tuneTest)
+	if x, y, z := compute.LocalSize(program); x != best.X || y != best.Y || z != best.Z {
+		log.Println("E", "Tuned program local size", x, y, z)
+	}
+	compute.UseProgram(program)
+	input.SetBinding(1)
+	output.SetBinding(2)
+	compute.SetInt("count", count)
+	compute.Realize((count+best.X-1)/best.X, 1, 1)
+	results := gc.BufferRead[float32](output, count)
+	for i, v := range results {
+		if v != values[i]*2+1 {
+			log.Println("E", "Tuned program result", i, v)
+			break
+		}
+	}
+	compute.DeleteProgram(program)
+}
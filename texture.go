@@ -1,25 +1,30 @@
 package gocompute
 
 import (
+	"errors"
 	"github.com/go-gl/gl/all-core/gl"
 	"log"
 	"runtime"
-	"runtime/debug"
+	"strconv"
 	"unsafe"
 )
 
 type GpuTexture struct {
-	id       uint32
-	channels int
-	texType  TextureType
-	typeSize int
-	sampler  uint32
-	levels   int32
-	level    int32
-	SizeX    int
-	SizeY    int
-	SizeZ    int
-	buffer   interface{}
+	id        uint32
+	channels  int
+	texType   TextureType
+	typeSize  int
+	sampler   uint32
+	levels    int32
+	level     int32
+	SizeX     int
+	SizeY     int
+	SizeZ     int
+	buffer    interface{}
+	format    TextureFormat
+	formatErr error
+	c         *Computing
+	res       *resource
 }
 type TextureType int
 
@@ -35,6 +40,14 @@ const (
 	SIGNED32
 	UNSIGNED32
 	FLOAT32
+	// Packed and special formats, channels count is fixed by format
+	R11G11B10F
+	RGB10A2
+	SRGB8A8
+	DEPTH16
+	DEPTH24
+	DEPTH32F
+	DEPTH24STENCIL8
 )
 
 func (c *Computing) NewTexture(texType TextureType, channels int) *GpuTexture {
@@ -43,17 +56,25 @@ func (c *Computing) NewTexture(texType TextureType, channels int) *GpuTexture {
 	t.texType = texType
 	t.levels = 1
 	t.level = 0
-	t.typeSize = 1
 	t.SizeX = 1
 	t.SizeY = 1
 	t.SizeZ = 1
-	switch {
-	case texType >= SIGNED32:
-		t.typeSize = 4
-	case texType >= SIGNED16:
-		t.typeSize = 2
+	t.format, t.formatErr = TextureFormatOf(texType, channels)
+	if t.formatErr != nil {
+		log.Println("E", "NewTexture:", t.formatErr)
 	}
-	gl.GenTextures(1, &t.id)
+	t.typeSize = t.format.TexelSize
+	if _, packed := packedFormats[texType]; !packed && channels > 0 {
+		t.typeSize /= channels
+	}
+	t.c = c
+	c.run(func() {
+		gl.GenTextures(1, &t.id)
+	})
+	t.res = c.track(ResourceTexture, t.id)
+	runtime.SetFinalizer(&t, func(t *GpuTexture) {
+		t.c.warnLeak(t.res)
+	})
 	return &t
 }
 
@@ -62,10 +83,14 @@ func (t *GpuTexture) GetID() uint32 {
 }
 
 func (t *GpuTexture) Bind() {
-	gl.BindTexture(t.sampler, t.id)
+	t.c.runShared(func() {
+		gl.BindTexture(t.sampler, t.id)
+	})
 }
 func (t *GpuTexture) UnBind() {
-	gl.BindTexture(t.sampler, 0)
+	t.c.runShared(func() {
+		gl.BindTexture(t.sampler, 0)
+	})
 }
 
 func (t *GpuTexture) SetLevels(levels int) {
@@ -80,46 +105,116 @@ func (t *GpuTexture) SetLevel(level int) {
 	}
 }
 
+// InternalBuffer Allocating texture-owned slice reused by TextureRead
+// Prefer TextureReadInto with caller slice when result must outlive next read
 func InternalBuffer[V any](t *GpuTexture) {
-	sys := debug.SetGCPercent(-1)
 	size := tSize[V]()
-	t.buffer = make([]V, t.SizeX*t.SizeY*t.SizeZ*t.channels*t.typeSize/size)
-	debug.SetGCPercent(sys)
+	t.buffer = make([]V, t.ByteSize()/size)
+}
+
+// storageSize Bytes of all allocated levels
+func (t *GpuTexture) storageSize() int {
+	size := 0
+	x, y, z := t.SizeX, t.SizeY, t.SizeZ
+	for level := int32(0); level < t.levels; level++ {
+		size += x * y * z * t.format.TexelSize
+		x, y, z = (x+1)/2, (y+1)/2, (z+1)/2
+	}
+	return size
+}
+
+// ByteSize Size in bytes of texture level data
+func (t *GpuTexture) ByteSize() int {
+	return t.SizeX * t.SizeY * t.SizeZ * t.format.TexelSize
 }
 
-func (t *GpuTexture) Create1D(X int) {
+// clientType Pixel type and texel size of client slice of V, Half data of float textures is passed as HALF_FLOAT
+func clientType[V any](t *GpuTexture) (uint32, int) {
+	if isHalf[V]() && (t.texType == FLOAT16 || t.texType == FLOAT32) {
+		return gl.HALF_FLOAT, t.channels * 2
+	}
+	return t.format.XType, t.format.TexelSize
+}
+
+// checkData Checking that slice covers count texels of texture, returns pixel type of slice
+func checkData[V any](t *GpuTexture, data []V, count int, operation string) (uint32, error) {
+	if t.formatErr != nil {
+		return 0, errors.New(operation + ": " + t.formatErr.Error())
+	}
+	xType, texelSize := clientType[V](t)
+	need := count * texelSize
+	have := len(data) * tSize[V]()
+	if have < need {
+		return 0, errors.New(operation + ": slice holds " + strconv.Itoa(have) + " bytes, texture requires " + strconv.Itoa(need))
+	}
+	return xType, nil
+}
+
+func (t *GpuTexture) Create1D(X int) error {
+	if t.formatErr != nil {
+		return t.formatErr
+	}
 	t.sampler = gl.TEXTURE_1D
-	t.Bind()
-	gl.TexStorage1D(t.sampler, t.levels, t.InternalFormat(), int32(X))
+	t.c.runShared(func() {
+		t.Bind()
+		gl.TexStorage1D(t.sampler, t.levels, t.InternalFormat(), int32(X))
+		CheckErr("TexStorage1D")
+	})
 	t.SizeX = X
 	t.SizeY = 1
 	t.SizeZ = 1
+	t.c.setSize(t.res, t.storageSize())
+	return nil
 }
-func (t *GpuTexture) Create2D(X, Y int) {
+func (t *GpuTexture) Create2D(X, Y int) error {
+	if t.formatErr != nil {
+		return t.formatErr
+	}
 	t.sampler = gl.TEXTURE_2D
-	t.Bind()
-	gl.TexStorage2D(t.sampler, t.levels, t.InternalFormat(), int32(X), int32(Y))
-	CheckErr("TexStorage2D")
+	t.c.runShared(func() {
+		t.Bind()
+		gl.TexStorage2D(t.sampler, t.levels, t.InternalFormat(), int32(X), int32(Y))
+		CheckErr("TexStorage2D")
+	})
 	t.SizeX = X
 	t.SizeY = Y
 	t.SizeZ = 1
+	t.c.setSize(t.res, t.storageSize())
+	return nil
 }
-func (t *GpuTexture) Create3D(X, Y, Z int) {
+func (t *GpuTexture) Create3D(X, Y, Z int) error {
+	if t.formatErr != nil {
+		return t.formatErr
+	}
 	t.sampler = gl.TEXTURE_3D
-	t.Bind()
-	gl.TexStorage3D(t.sampler, t.levels, t.InternalFormat(), int32(X), int32(Y), int32(Z))
+	t.c.runShared(func() {
+		t.Bind()
+		gl.TexStorage3D(t.sampler, t.levels, t.InternalFormat(), int32(X), int32(Y), int32(Z))
+		CheckErr("TexStorage3D")
+	})
 	t.SizeX = X
 	t.SizeY = Y
 	t.SizeZ = Z
+	t.c.setSize(t.res, t.storageSize())
+	return nil
 }
 
+// TextureLoad1DRange Uploading data directly from caller slice, slice is kept alive until GL copied it
 func TextureLoad1DRange[V any](t *GpuTexture, data []V, offset int) {
 	if t.check() {
 		return
 	}
-	t.Bind()
-	gl.TexSubImage1D(t.sampler, 0, int32(offset), int32(t.SizeX), t.Format(), t.XType(), unsafe.Pointer(&data[0]))
-	CheckErr("TextureSubImage1D")
+	xType, err := checkData(t, data, t.SizeX, "TextureLoad1DRange")
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	t.c.runShared(func() {
+		t.Bind()
+		gl.TexSubImage1D(t.sampler, 0, int32(offset), int32(t.SizeX), t.Format(), xType, unsafe.Pointer(&data[0]))
+		runtime.KeepAlive(data)
+		CheckErr("TextureSubImage1D")
+	})
 	t.UnBind()
 }
 
@@ -127,16 +222,17 @@ func TextureLoad2DRange[V any](t *GpuTexture, data []V, offsetX, offsetY int) {
 	if t.check() {
 		return
 	}
-	sys := debug.SetGCPercent(-1)
-	t.Bind()
-	if t.buffer != nil {
-		copy(t.buffer.([]V), data)
-		gl.TexSubImage2D(t.sampler, 0, int32(offsetX), int32(offsetY), int32(t.SizeX), int32(t.SizeY), t.Format(), t.XType(), unsafe.Pointer(&t.buffer.([]V)[0]))
-	} else {
-		gl.TexSubImage2D(t.sampler, 0, int32(offsetX), int32(offsetY), int32(t.SizeX), int32(t.SizeY), t.Format(), t.XType(), unsafe.Pointer(&data[0]))
+	xType, err := checkData(t, data, t.SizeX*t.SizeY, "TextureLoad2DRange")
+	if err != nil {
+		log.Println("E", err)
+		return
 	}
-	CheckErr("TexSubImage2D")
-	debug.SetGCPercent(sys)
+	t.c.runShared(func() {
+		t.Bind()
+		gl.TexSubImage2D(t.sampler, 0, int32(offsetX), int32(offsetY), int32(t.SizeX), int32(t.SizeY), t.Format(), xType, unsafe.Pointer(&data[0]))
+		runtime.KeepAlive(data)
+		CheckErr("TexSubImage2D")
+	})
 	//t.UnBind()
 }
 
@@ -144,9 +240,17 @@ func TextureLoad3DRange[V any](t *GpuTexture, data []V, offsetX, offsetY, offset
 	if t.check() {
 		return
 	}
-	t.Bind()
-	t.SizeX = len(data)
-	gl.TexSubImage3D(t.sampler, 0, int32(offsetX), int32(offsetY), int32(offsetZ), int32(t.SizeX), int32(t.SizeY), int32(t.SizeZ), t.Format(), t.XType(), unsafe.Pointer(&data[0]))
+	xType, err := checkData(t, data, t.SizeX*t.SizeY*t.SizeZ, "TextureLoad3DRange")
+	if err != nil {
+		log.Println("E", err)
+		return
+	}
+	t.c.runShared(func() {
+		t.Bind()
+		gl.TexSubImage3D(t.sampler, 0, int32(offsetX), int32(offsetY), int32(offsetZ), int32(t.SizeX), int32(t.SizeY), int32(t.SizeZ), t.Format(), xType, unsafe.Pointer(&data[0]))
+		runtime.KeepAlive(data)
+		CheckErr("TexSubImage3D")
+	})
 	//t.UnBind()
 }
 
@@ -162,24 +266,68 @@ func TextureLoad1D[V any](t *GpuTexture, data []V) {
 	TextureLoad1DRange(t, data, 0)
 }
 
-func TextureRead[V any](t *GpuTexture) []V {
-	t.Bind()
-	if t.buffer != nil {
+// TextureReadInto Reading current texture level directly into caller slice
+// Slice must hold at least ByteSize() bytes
+func TextureReadInto[V any](t *GpuTexture, dst []V) error {
+	if t.check() {
+		return errors.New("TextureReadInto: texture already closed")
+	}
+	xType, err := checkData(t, dst, t.SizeX*t.SizeY*t.SizeZ, "TextureReadInto")
+	if err != nil {
+		return err
+	}
+	t.c.runShared(func() {
+		t.Bind()
 		gl.GetTextureSubImage(t.id, t.level, 0, 0, 0, int32(t.SizeX), int32(t.SizeY), int32(t.SizeZ),
-			t.Format(), t.XType(), int32(t.SizeX*t.SizeY*t.SizeZ*t.channels*t.typeSize), unsafe.Pointer(&t.buffer.([]V)[0]))
-	} else {
-		log.Println("E", "Texture internal buffer is nil! Internal buffer is required for reading texture")
+			t.Format(), xType, int32(len(dst)*tSize[V]()), unsafe.Pointer(&dst[0]))
+		runtime.KeepAlive(dst)
+		CheckErr("GetTextureSubImage")
+	})
+	return nil
+}
+
+// TextureRead Reading texture into internal buffer, returned slice is overwritten on every read
+// Without InternalBuffer new slice is allocated for each call
+func TextureRead[V any](t *GpuTexture) []V {
+	buffer, ok := t.buffer.([]V)
+	if !ok {
+		buffer = make([]V, t.ByteSize()/tSize[V]())
+	}
+	if err := TextureReadInto(t, buffer); err != nil {
+		log.Println("E", err)
+		return nil
 	}
-	CheckErr("GetTextureSubImage")
-	return t.buffer.([]V)
+	return buffer
 }
 
-func (t *GpuTexture) SetBinding(number int) {
+// SetBinding Binding texture level to image unit, format must support image load store
+func (t *GpuTexture) SetBinding(number int) (err error) {
 	if t.check() {
-		return
+		return errors.New("SetBinding: texture already closed")
+	}
+	if t.formatErr != nil {
+		return t.formatErr
 	}
-	gl.BindImageTexture(uint32(number), t.id, t.level, false, 0, gl.READ_WRITE, t.InternalFormat())
-	CheckErr("BindImageTexture")
+	if !t.format.ImageLoadStore() {
+		err = errors.New("SetBinding: texture format " + strconv.Itoa(int(t.format.Internal)) + " is not supported by image load store")
+		log.Println("E", err)
+		return err
+	}
+	t.c.runShared(func() {
+		//Limits of context the image is bound in, cached after first query
+		device := t.c.bound().deviceInfo()
+		if number < 0 || number >= device.MaxImageUnits {
+			err = errors.New("SetBinding: image unit " + strconv.Itoa(number) + " is out of range, device has " + strconv.Itoa(device.MaxImageUnits))
+			return
+		}
+		if device.ImageFormats != nil && !device.SupportsImageFormat(t.Type(), t.Channels()) {
+			err = errors.New("SetBinding: device doesn't support image load store of " + t.Type().String() + " with " + strconv.Itoa(t.Channels()) + " channels")
+			return
+		}
+		gl.BindImageTexture(uint32(number), t.id, t.level, false, 0, gl.READ_WRITE, t.InternalFormat())
+		CheckErr("BindImageTexture")
+	})
+	return err
 }
 
 func (t *GpuTexture) Read() []byte {
@@ -192,207 +340,55 @@ func (t *GpuTexture) ReadFloat32() []float32 {
 	return TextureRead[float32](t)
 }
 
+func (t *GpuTexture) ReadInto(dst []byte) error {
+	return TextureReadInto(t, dst)
+}
+func (t *GpuTexture) ReadInt32Into(dst []int32) error {
+	return TextureReadInto(t, dst)
+}
+func (t *GpuTexture) ReadFloat32Into(dst []float32) error {
+	return TextureReadInto(t, dst)
+}
+func (t *GpuTexture) ReadHalfInto(dst []Half) error {
+	return TextureReadInto(t, dst)
+}
+
 func (t *GpuTexture) Type() TextureType {
 	return t.texType
 }
 func (t *GpuTexture) Channels() int {
 	return t.channels
 }
+
+// TypeSize Bytes per channel in client memory, packed formats report size of whole texel
 func (t *GpuTexture) TypeSize() int {
 	return t.typeSize
 }
 func (t *GpuTexture) InternalFormat() uint32 {
-	switch t.texType {
-	case SIGNED8:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R8I
-			case 2:
-				return gl.RG8I
-			case 3:
-				return gl.RGB8I
-			case 4:
-				return gl.RGBA8I
-			}
-		}
-	case UNSIGNED8:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R8UI
-			case 2:
-				return gl.RG8UI
-			case 3:
-				return gl.RGB8UI
-			case 4:
-				return gl.RGBA8UI
-			}
-		}
-	case SIMPLE8:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R8
-			case 2:
-				return gl.RG8
-			case 3:
-				return gl.RGB8
-			case 4:
-				return gl.RGBA8
-			}
-		}
-	case SIGNED16:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R16I
-			case 2:
-				return gl.RG16I
-			case 3:
-				return gl.RGB16I
-			case 4:
-				return gl.RGBA16I
-			}
-		}
-	case UNSIGNED16:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R16UI
-			case 2:
-				return gl.RG16UI
-			case 3:
-				return gl.RGB16UI
-			case 4:
-				return gl.RGBA16UI
-			}
-		}
-	case SIMPLE16:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R16
-			case 2:
-				return gl.RG16
-			case 3:
-				return gl.RGB16
-			case 4:
-				return gl.RGBA16
-			}
-		}
-	case FLOAT16:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R16F
-			case 2:
-				return gl.RG16F
-			case 3:
-				return gl.RGB16F
-			case 4:
-				return gl.RGBA16F
-			}
-		}
-	case SIGNED32:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R32I
-			case 2:
-				return gl.RG32I
-			case 3:
-				return gl.RGB32I
-			case 4:
-				return gl.RGBA32I
-			}
-		}
-	case UNSIGNED32:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R32UI
-			case 2:
-				return gl.RG32UI
-			case 3:
-				return gl.RGB32UI
-			case 4:
-				return gl.RGBA32UI
-			}
-		}
-	case FLOAT32:
-		{
-			switch t.channels {
-			case 1:
-				return gl.R32F
-			case 2:
-				return gl.RG32F
-			case 3:
-				return gl.RGB32F
-			case 4:
-				return gl.RGBA32F
-			}
-		}
-	}
-	return gl.RGBA8
+	return t.format.Internal
 }
 func (t *GpuTexture) Format() uint32 {
-	switch t.texType {
-	case SIMPLE8, SIMPLE16, SIGNED8, UNSIGNED8, FLOAT16, FLOAT32:
-		{
-			switch t.channels {
-			case 1:
-				return gl.RED
-			case 2:
-				return gl.RG
-			case 3:
-				return gl.RGB
-			case 4:
-				return gl.RGBA
-			}
-		}
-	case SIGNED16, UNSIGNED16, SIGNED32, UNSIGNED32:
-		{
-			switch t.channels {
-			case 1:
-				return gl.RED_INTEGER
-			case 2:
-				return gl.RG_INTEGER
-			case 3:
-				return gl.RGB_INTEGER
-			case 4:
-				return gl.RGBA_INTEGER
-			}
-		}
-	}
-	return gl.RGBA
+	return t.format.Format
 }
-
 func (t *GpuTexture) XType() uint32 {
-	switch t.texType {
-	case FLOAT16, FLOAT32:
-		return gl.FLOAT
-	case SIMPLE8, UNSIGNED8:
-		return gl.UNSIGNED_BYTE
-	case SIGNED8:
-		return gl.BYTE
-	case SIGNED16:
-		return gl.UNSIGNED_SHORT
-	case UNSIGNED16:
-		return gl.SHORT
-	case SIGNED32:
-		return gl.INT
-	case UNSIGNED32:
-		return gl.UNSIGNED_INT
-	case SIMPLE16:
-		return gl.UNSIGNED_SHORT
-	}
-	return gl.FLOAT
+	return t.format.XType
+}
+
+// TexelSize Bytes per texel in client memory
+func (t *GpuTexture) TexelSize() int {
+	return t.format.TexelSize
 }
+
+// TextureFormat Resolved GL formats of texture, error for unsupported type and channels combination
+func (t *GpuTexture) TextureFormat() (TextureFormat, error) {
+	return t.format, t.formatErr
+}
+
+// check Texture was closed by its own Close or freed by Computing.Close
 func (t *GpuTexture) check() bool {
-	if t.id == 0xFFFFFFFF {
+	if t.id == 0xFFFFFFFF || t.c.isClosed(t.res) {
 		println("Texture object with ID:", t.id, "already closed!")
-		return t.id == 0xFFFFFFFF
+		return true
 	}
 	return false
 }
@@ -407,6 +403,17 @@ func (t *GpuTexture) Load3D(data []byte) {
 	TextureLoad3D(t, data)
 }
 
+// Load1DHalf Uploading half floats without conversion, texture type must be FLOAT16 or FLOAT32
+func (t *GpuTexture) Load1DHalf(data []Half) {
+	TextureLoad1D(t, data)
+}
+func (t *GpuTexture) Load2DHalf(data []Half) {
+	TextureLoad2D(t, data)
+}
+func (t *GpuTexture) Load3DHalf(data []Half) {
+	TextureLoad3D(t, data)
+}
+
 func (t *GpuTexture) Load1DFloat32(data []float32) {
 	TextureLoad1D(t, data)
 }
@@ -418,10 +425,12 @@ func (t *GpuTexture) Load3DFloat32(data []float32) {
 }
 
 func (t *GpuTexture) Close() {
-	if t.check() {
+	runtime.KeepAlive(t.buffer)
+	if !t.c.untrack(t.res) {
 		return
 	}
-	runtime.KeepAlive(t.buffer)
-	gl.DeleteTextures(1, &t.id)
-	t.id = 0xFFFFFFFF
+	t.c.runShared(func() {
+		gl.DeleteTextures(1, &t.id)
+		t.id = 0xFFFFFFFF
+	})
 }
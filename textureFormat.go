@@ -0,0 +1,146 @@
+package gocompute
+
+import (
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"strconv"
+)
+
+// TextureFormat GL description of TextureType with channels count
+type TextureFormat struct {
+	// Internal sized internal format used for texture storage
+	Internal uint32
+	// Format pixel format of client data
+	Format uint32
+	// XType pixel type of client data
+	XType uint32
+	// TexelSize bytes per texel in client memory
+	TexelSize int
+	// Layout GLSL image format qualifier, empty when format can't be used with image load store
+	Layout string
+}
+
+// ImageLoadStore Format can be bound with SetBinding
+func (f TextureFormat) ImageLoadStore() bool {
+	return f.Layout != ""
+}
+
+type formatEntry struct {
+	internal uint32
+	layout   string
+}
+
+type formatFamily struct {
+	// pixel formats by channels count
+	formats [4]uint32
+	xType   uint32
+	// client bytes per channel
+	size    int
+	entries [4]formatEntry
+}
+
+var normFormats = [4]uint32{gl.RED, gl.RG, gl.RGB, gl.RGBA}
+var intFormats = [4]uint32{gl.RED_INTEGER, gl.RG_INTEGER, gl.RGB_INTEGER, gl.RGBA_INTEGER}
+
+// Per channel format families, RGB formats are valid for storage but not for image load store
+var formatFamilies = map[TextureType]formatFamily{
+	SIGNED8: {intFormats, gl.BYTE, 1, [4]formatEntry{
+		{gl.R8I, "r8i"}, {gl.RG8I, "rg8i"}, {gl.RGB8I, ""}, {gl.RGBA8I, "rgba8i"}}},
+	UNSIGNED8: {intFormats, gl.UNSIGNED_BYTE, 1, [4]formatEntry{
+		{gl.R8UI, "r8ui"}, {gl.RG8UI, "rg8ui"}, {gl.RGB8UI, ""}, {gl.RGBA8UI, "rgba8ui"}}},
+	SIMPLE8: {normFormats, gl.UNSIGNED_BYTE, 1, [4]formatEntry{
+		{gl.R8, "r8"}, {gl.RG8, "rg8"}, {gl.RGB8, ""}, {gl.RGBA8, "rgba8"}}},
+	SIGNED16: {intFormats, gl.SHORT, 2, [4]formatEntry{
+		{gl.R16I, "r16i"}, {gl.RG16I, "rg16i"}, {gl.RGB16I, ""}, {gl.RGBA16I, "rgba16i"}}},
+	UNSIGNED16: {intFormats, gl.UNSIGNED_SHORT, 2, [4]formatEntry{
+		{gl.R16UI, "r16ui"}, {gl.RG16UI, "rg16ui"}, {gl.RGB16UI, ""}, {gl.RGBA16UI, "rgba16ui"}}},
+	SIMPLE16: {normFormats, gl.UNSIGNED_SHORT, 2, [4]formatEntry{
+		{gl.R16, "r16"}, {gl.RG16, "rg16"}, {gl.RGB16, ""}, {gl.RGBA16, "rgba16"}}},
+	//Client data of FLOAT16 textures is float32, driver converts it. Half slices are passed as HALF_FLOAT
+	FLOAT16: {normFormats, gl.FLOAT, 4, [4]formatEntry{
+		{gl.R16F, "r16f"}, {gl.RG16F, "rg16f"}, {gl.RGB16F, ""}, {gl.RGBA16F, "rgba16f"}}},
+	SIGNED32: {intFormats, gl.INT, 4, [4]formatEntry{
+		{gl.R32I, "r32i"}, {gl.RG32I, "rg32i"}, {gl.RGB32I, ""}, {gl.RGBA32I, "rgba32i"}}},
+	UNSIGNED32: {intFormats, gl.UNSIGNED_INT, 4, [4]formatEntry{
+		{gl.R32UI, "r32ui"}, {gl.RG32UI, "rg32ui"}, {gl.RGB32UI, ""}, {gl.RGBA32UI, "rgba32ui"}}},
+	FLOAT32: {normFormats, gl.FLOAT, 4, [4]formatEntry{
+		{gl.R32F, "r32f"}, {gl.RG32F, "rg32f"}, {gl.RGB32F, ""}, {gl.RGBA32F, "rgba32f"}}},
+}
+
+// Packed formats with fixed channels count
+var packedFormats = map[TextureType]struct {
+	channels int
+	format   TextureFormat
+}{
+	R11G11B10F:      {3, TextureFormat{gl.R11F_G11F_B10F, gl.RGB, gl.UNSIGNED_INT_10F_11F_11F_REV, 4, "r11f_g11f_b10f"}},
+	RGB10A2:         {4, TextureFormat{gl.RGB10_A2, gl.RGBA, gl.UNSIGNED_INT_2_10_10_10_REV, 4, "rgb10_a2"}},
+	SRGB8A8:         {4, TextureFormat{gl.SRGB8_ALPHA8, gl.RGBA, gl.UNSIGNED_BYTE, 4, ""}},
+	DEPTH16:         {1, TextureFormat{gl.DEPTH_COMPONENT16, gl.DEPTH_COMPONENT, gl.UNSIGNED_SHORT, 2, ""}},
+	DEPTH24:         {1, TextureFormat{gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT, gl.UNSIGNED_INT, 4, ""}},
+	DEPTH32F:        {1, TextureFormat{gl.DEPTH_COMPONENT32F, gl.DEPTH_COMPONENT, gl.FLOAT, 4, ""}},
+	DEPTH24STENCIL8: {1, TextureFormat{gl.DEPTH24_STENCIL8, gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8, 4, ""}},
+}
+
+// TextureFormatOf Resolving GL formats for TextureType with channels count
+func TextureFormatOf(texType TextureType, channels int) (TextureFormat, error) {
+	if packed, ok := packedFormats[texType]; ok {
+		if channels != packed.channels {
+			return TextureFormat{}, errors.New("texture type " + texType.String() + " requires " +
+				strconv.Itoa(packed.channels) + " channels, got " + strconv.Itoa(channels))
+		}
+		return packed.format, nil
+	}
+	family, ok := formatFamilies[texType]
+	if !ok {
+		return TextureFormat{}, errors.New("unsupported texture type " + texType.String())
+	}
+	if channels < 1 || channels > 4 {
+		return TextureFormat{}, errors.New("unsupported channels count " + strconv.Itoa(channels))
+	}
+	entry := family.entries[channels-1]
+	return TextureFormat{
+		Internal:  entry.internal,
+		Format:    family.formats[channels-1],
+		XType:     family.xType,
+		TexelSize: family.size * channels,
+		Layout:    entry.layout,
+	}, nil
+}
+
+var textureTypeNames = map[TextureType]string{
+	NONE:            "NONE",
+	SIGNED8:         "SIGNED8",
+	UNSIGNED8:       "UNSIGNED8",
+	SIMPLE8:         "SIMPLE8",
+	SIGNED16:        "SIGNED16",
+	UNSIGNED16:      "UNSIGNED16",
+	SIMPLE16:        "SIMPLE16",
+	FLOAT16:         "FLOAT16",
+	SIGNED32:        "SIGNED32",
+	UNSIGNED32:      "UNSIGNED32",
+	FLOAT32:         "FLOAT32",
+	R11G11B10F:      "R11G11B10F",
+	RGB10A2:         "RGB10A2",
+	SRGB8A8:         "SRGB8A8",
+	DEPTH16:         "DEPTH16",
+	DEPTH24:         "DEPTH24",
+	DEPTH32F:        "DEPTH32F",
+	DEPTH24STENCIL8: "DEPTH24STENCIL8",
+}
+
+func (t TextureType) String() string {
+	if name, ok := textureTypeNames[t]; ok {
+		return name
+	}
+	return "TextureType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// ParseTextureType Inverse of TextureType.String
+func ParseTextureType(name string) (TextureType, error) {
+	for texType, typeName := range textureTypeNames {
+		if typeName == name {
+			return texType, nil
+		}
+	}
+	return NONE, errors.New("unknown texture type " + name)
+}
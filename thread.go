@@ -0,0 +1,19 @@
+package gocompute
+
+/*
+#include <stdint.h>
+#ifdef _WIN32
+#include <windows.h>
+static uint64_t currentThread() { return (uint64_t)GetCurrentThreadId(); }
+#else
+#include <pthread.h>
+static uint64_t currentThread() { return (uint64_t)(uintptr_t)pthread_self(); }
+#endif
+*/
+import "C"
+
+// currentThread Id of OS thread running caller
+// GL thread is locked to its OS thread, so no other goroutine ever observes the same id
+func currentThread() uint64 {
+	return uint64(C.currentThread())
+}
@@ -0,0 +1,290 @@
+package gocompute
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/go-gl/gl/all-core/gl"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Defines holding local size of tunable program, source declares defaults like "#define LOCAL_SIZE_X 64"
+// and uses them in "layout(local_size_x = LOCAL_SIZE_X, ...) in;"
+const (
+	LocalSizeX = "LOCAL_SIZE_X"
+	LocalSizeY = "LOCAL_SIZE_Y"
+	LocalSizeZ = "LOCAL_SIZE_Z"
+)
+
+// WorkGroup Local work group size
+type WorkGroup struct {
+	X, Y, Z int
+}
+
+func (g WorkGroup) invocations() int {
+	return g.X * g.Y * g.Z
+}
+
+// defines Local size defines selecting group
+func (g WorkGroup) defines() map[string]string {
+	return map[string]string{LocalSizeX: strconv.Itoa(g.X), LocalSizeY: strconv.Itoa(g.Y), LocalSizeZ: strconv.Itoa(g.Z)}
+}
+
+func (g WorkGroup) String() string {
+	return strconv.Itoa(g.X) + "x" + strconv.Itoa(g.Y) + "x" + strconv.Itoa(g.Z)
+}
+
+// Candidates1D Work group sizes tried for programs over one dimension
+func Candidates1D() []WorkGroup {
+	return []WorkGroup{{32, 1, 1}, {64, 1, 1}, {128, 1, 1}, {256, 1, 1}, {512, 1, 1}, {1024, 1, 1}}
+}
+
+// Candidates2D Work group sizes tried for programs over images
+func Candidates2D() []WorkGroup {
+	return []WorkGroup{{8, 4, 1}, {8, 8, 1}, {16, 8, 1}, {16, 16, 1}, {32, 8, 1}, {32, 16, 1}, {32, 32, 1}, {64, 4, 1}}
+}
+
+// TuningEntry Best work group of kernel on device
+type TuningEntry struct {
+	Kernel string
+	Device string
+	Group  WorkGroup
+	// Nanoseconds GPU time of best run
+	Nanoseconds uint64
+}
+
+// TuningDB On-disk database of autotuned work groups, keyed by kernel hash and device
+type TuningDB struct {
+	path    string
+	mutex   sync.Mutex
+	entries map[string]TuningEntry
+}
+
+// OpenTuningDB Reading database from json file, missing file starts empty database
+func OpenTuningDB(path string) (*TuningDB, error) {
+	db := &TuningDB{path: path, entries: make(map[string]TuningEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []TuningEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.New("OpenTuningDB: " + path + ": " + err.Error())
+	}
+	for _, e := range entries {
+		db.entries[e.Kernel+"\x00"+e.Device] = e
+	}
+	return db, nil
+}
+
+// Lookup Stored work group of kernel on device
+func (db *TuningDB) Lookup(kernel, device string) (TuningEntry, bool) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	e, ok := db.entries[kernel+"\x00"+device]
+	return e, ok
+}
+
+// Store Saving entry and writing database atomically
+func (db *TuningDB) Store(e TuningEntry) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.entries[e.Kernel+"\x00"+e.Device] = e
+	entries := make([]TuningEntry, 0, len(db.entries))
+	for _, entry := range db.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kernel != entries[j].Kernel {
+			return entries[i].Kernel < entries[j].Kernel
+		}
+		return entries[i].Device < entries[j].Device
+	})
+	data, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(db.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+"*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), db.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Len Number of stored entries
+func (db *TuningDB) Len() int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	return len(db.entries)
+}
+
+// SetTuningDB Using database for following LoadProgram, ReplaceProgram, WatchProgram and Autotune calls, nil disables tuning
+// Local size defines of loaded programs are taken from database unless defined explicitly
+func (c *Computing) SetTuningDB(db *TuningDB) {
+	c.run(func() {
+		c.tuning = db
+	})
+}
+
+// kernelHash Hash of source and user defines other than local sizes
+func (c *Computing) kernelHash(programText string) string {
+	names := make([]string, 0, len(c.defineMap))
+	for name := range c.defineMap {
+		if name != LocalSizeX && name != LocalSizeY && name != LocalSizeZ {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	hash.Write([]byte(programText + "\x00"))
+	for _, name := range names {
+		hash.Write([]byte(name + "=" + c.defineMap[name] + "\x00"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// tunable Source takes local size from defines
+func tunable(programText string) bool {
+	return strings.Contains(programText, LocalSizeX)
+}
+
+// tunedDefines Local size defines from database missing in define map, must run on GL thread
+func (c *Computing) tunedDefines(programText string) map[string]string {
+	if c.tuning == nil || !tunable(programText) {
+		return nil
+	}
+	entry, ok := c.tuning.Lookup(c.kernelHash(programText), c.deviceInfo().driver())
+	if !ok {
+		return nil
+	}
+	defines := make(map[string]string)
+	for name, value := range entry.Group.defines() {
+		if _, set := c.defineMap[name]; !set {
+			defines[name] = value
+		}
+	}
+	return defines
+}
+
+// withDefines Replacing define map with copy holding extra defines, returned function restores it
+func (c *Computing) withDefines(extra map[string]string) func() {
+	defines := c.defineMap
+	c.defineMap = make(map[string]string, len(defines)+len(extra))
+	for name, value := range defines {
+		c.defineMap[name] = value
+	}
+	for name, value := range extra {
+		c.defineMap[name] = value
+	}
+	return func() {
+		c.defineMap = defines
+	}
+}
+
+// timeRun GPU nanoseconds spent by commands issued from run
+func timeRun(query uint32, run func() error) (uint64, error) {
+	gl.BeginQuery(gl.TIME_ELAPSED, query)
+	err := run()
+	gl.EndQuery(gl.TIME_ELAPSED)
+	elapsed := uint64(0)
+	gl.GetQueryObjectui64v(query, gl.QUERY_RESULT, &elapsed)
+	return elapsed, err
+}
+
+// Autotune Compiling program with every candidate local size and keeping the fastest
+// Program declares LOCAL_SIZE_X/Y/Z defines, run binds representative inputs and dispatches
+// program for given group, best of repeats GPU timings counts, result is stored in tuning database
+func (c *Computing) Autotune(programText string, candidates []WorkGroup, repeats int, run func(program int, group WorkGroup) error) (best WorkGroup, err error) {
+	if !tunable(programText) {
+		return best, errors.New("Autotune: program doesn't use " + LocalSizeX + " define")
+	}
+	if repeats < 1 {
+		repeats = 1
+	}
+	err = c.DoErr(func() error {
+		device := c.deviceInfo()
+		limit := WorkGroup{device.MaxWorkGroupSize[0], device.MaxWorkGroupSize[1], device.MaxWorkGroupSize[2]}
+		invocations := device.MaxWorkGroupInvocations
+		bestTime := uint64(math.MaxUint64)
+		kernel := c.kernelHash(programText)
+		query := uint32(0)
+		gl.GenQueries(1, &query)
+		defer gl.DeleteQueries(1, &query)
+		var lastErr error
+		for _, group := range candidates {
+			if group.X < 1 || group.Y < 1 || group.Z < 1 || group.X > limit.X || group.Y > limit.Y || group.Z > limit.Z ||
+				group.invocations() > invocations {
+				continue
+			}
+			restore := c.withDefines(group.defines())
+			program, loadErr := c.loadProgram(programText)
+			restore()
+			if loadErr != nil {
+				//Group may exceed shared memory or register limits of kernel
+				lastErr = errors.New("Autotune " + group.String() + ": " + loadErr.Error())
+				continue
+			}
+			measure := func() error {
+				c.UseProgram(program)
+				return run(program, group)
+			}
+			//Warm up caches and lazy driver compilation
+			if err := measure(); err != nil {
+				c.DeleteProgram(program)
+				return err
+			}
+			fastest := uint64(math.MaxUint64)
+			for i := 0; i < repeats; i++ {
+				elapsed, err := timeRun(query, measure)
+				if err != nil {
+					c.DeleteProgram(program)
+					return err
+				}
+				if elapsed < fastest {
+					fastest = elapsed
+				}
+			}
+			c.DeleteProgram(program)
+			if fastest < bestTime {
+				best, bestTime = group, fastest
+			}
+		}
+		CheckErr("Autotune")
+		if bestTime == math.MaxUint64 {
+			if lastErr != nil {
+				return lastErr
+			}
+			return errors.New("Autotune: no candidate fits work group limits " + limit.String())
+		}
+		if c.tuning != nil {
+			return c.tuning.Store(TuningEntry{Kernel: kernel, Device: c.deviceInfo().driver(), Group: best, Nanoseconds: bestTime})
+		}
+		return nil
+	})
+	return best, err
+}